@@ -46,19 +46,10 @@ const UserProfile = () => {
 
   const handleLogoutClick = async () => {
     try {
-      // Send logout request to backend with user info
+      // Ask the backend to revoke the session cookie
       await fetch(process.env.REACT_APP_SERVER_URL+'/auth/logout', {
         method: 'POST',
-        headers: {
-          'Content-Type': 'application/json',
-          'X-User-ID': user.id,
-          'X-User-Email': user.email,
-        },
-        body: JSON.stringify({
-          userId: user.id,
-          email: user.email,
-          name: user.name,
-        }),
+        credentials: 'include',
       });
       
       console.log('[AUTH] Logout request sent to backend');
@@ -15,9 +15,8 @@ const AuthCallback = () => {
       return;
     }
 
-    const handleAuthCallback = () => {
+    const handleAuthCallback = async () => {
       hasProcessed.current = true;
-      const userParam = searchParams.get('user');
       const error = searchParams.get('error');
 
       if (error) {
@@ -26,18 +25,23 @@ const AuthCallback = () => {
         return;
       }
 
-      if (userParam) {
-        try {
-          const userData = JSON.parse(decodeURIComponent(userParam));
-          console.log('User data received:', userData);
-          handleLogin(userData);
-          navigate('/');
-        } catch (error) {
-          console.error('Error parsing user data:', error);
-          navigate('/login?error=parse_error');
+      try {
+        // The session cookie set by the backend identifies the user
+        const response = await fetch(process.env.REACT_APP_SERVER_URL + '/api/profile', {
+          credentials: 'include',
+        });
+        const data = await response.json();
+
+        if (!response.ok || !data.success) {
+          navigate('/login?error=no_user_data');
+          return;
         }
-      } else {
-        navigate('/login?error=no_user_data');
+
+        handleLogin(data.user);
+        navigate(searchParams.get('return_to') || '/');
+      } catch (error) {
+        console.error('Error loading user profile:', error);
+        navigate('/login?error=parse_error');
       }
     };
 
@@ -86,6 +86,8 @@ const LoginPage = () => {
         decode_failed: 'Failed to process user data.',
         parse_error: 'Failed to parse authentication data.',
         no_user_data: 'No user data received.',
+        invalid_state: 'Your sign-in session expired. Please try again.',
+        session_failed: 'Failed to start your session. Please try again.',
       };
       setError(errorMessages[errorParam] || 'An unknown error occurred.');
     }
@@ -96,7 +98,8 @@ const LoginPage = () => {
     setError('');
     
     // Redirect to backend OAuth endpoint
-    window.location.href = process.env.REACT_APP_SERVER_URL + '/auth/google/login';
+    const returnTo = searchParams.get('return_to') || '/';
+    window.location.href = process.env.REACT_APP_SERVER_URL + '/auth/google/login?return_to=' + encodeURIComponent(returnTo);
   };
 
   const handleBackToHome = () => {
@@ -111,7 +111,8 @@ const SeatSelection = () => {
     }
     if (currentStep === 0 && !isAuthenticated) {
       // Redirect to authentication
-      const loginUrl = `${process.env.REACT_APP_SERVER_URL}/auth/google/login`;
+      const returnTo = encodeURIComponent(location.pathname + location.search);
+      const loginUrl = `${process.env.REACT_APP_SERVER_URL}/auth/google/login?return_to=${returnTo}`;
       window.location.href = loginUrl;
       return;
     }
@@ -170,7 +171,7 @@ const SeatSelection = () => {
     const isSelected = selectedSeats.some(s => s.seat_id === seatId);
     
     if (isSelected) return '#ff6b35';
-    if (seat.status === 'booked') return '#757575';
+    if (seat.status === 'booked' || seat.status === 'held') return '#757575';
     if (seat.status === 'blocked') return '#ffa726';
     if (seat.status === 'maintenance') return '#f44336';
     return '#4caf50'; // available
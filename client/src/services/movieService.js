@@ -168,6 +168,7 @@ class MovieService {
     try {
       const response = await fetch(`${API_BASE_URL}/api/bookings`, {
         method: 'POST',
+        credentials: 'include',
         headers: {
           'Content-Type': 'application/json',
         },
@@ -204,11 +205,8 @@ class MovieService {
         headers: {
           'Content-Type': 'application/json',
         },
-        body: JSON.stringify({
-          ...bookingData,
-          googleId: user.googleId || user.id,   // put here
-        }),
-      
+        credentials: 'include',
+        body: JSON.stringify(bookingData),
       });
       
       if (!response.ok) {
@@ -271,7 +269,9 @@ class MovieService {
    */
   static async getBookingDetails(bookingId) {
     try {
-      const response = await fetch(`${API_BASE_URL}/api/bookings/${bookingId}`);
+      const response = await fetch(`${API_BASE_URL}/api/bookings/${bookingId}`, {
+        credentials: 'include',
+      });
       
       if (!response.ok) {
         throw new Error(`HTTP error! status: ${response.status}`);
@@ -300,6 +300,7 @@ class MovieService {
     try {
       const response = await fetch(`${API_BASE_URL}/api/bookings/${bookingId}/payment`, {
         method: 'PUT',
+        credentials: 'include',
         headers: {
           'Content-Type': 'application/json',
         },
@@ -0,0 +1,167 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const BookingsCollection = "bookings"
+
+// BookingRepository handles booking database operations used outside of the HTTP handlers
+type BookingRepository struct {
+	collection *mongo.Collection
+}
+
+// NewBookingRepository creates a new BookingRepository instance
+func NewBookingRepository() *BookingRepository {
+	return &BookingRepository{
+		collection: config.GetCollection(BookingsCollection),
+	}
+}
+
+// EnsureIndexes makes booking references unique and supports the lookups used by the workers
+func (r *BookingRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "booking_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "payment_status", Value: 1}, {Key: "expires_at", Value: 1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create booking indexes: %w", err)
+	}
+	return nil
+}
+
+// FindExpiredPendingBookings returns unpaid bookings whose payment window closed before the given time
+func (r *BookingRepository) FindExpiredPendingBookings(ctx context.Context, before time.Time, limit int64) ([]models.Booking, error) {
+	filter := bson.M{
+		"booking_status": "confirmed",
+		"payment_status": "pending",
+		"expires_at":     bson.M{"$lt": before},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetLimit(limit))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired bookings: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var bookings []models.Booking
+	if err := cursor.All(ctx, &bookings); err != nil {
+		return nil, fmt.Errorf("failed to decode expired bookings: %w", err)
+	}
+
+	return bookings, nil
+}
+
+// MarkBookingExpired expires an unpaid booking.
+// It reports false when the booking was paid or cancelled in the meantime.
+func (r *BookingRepository) MarkBookingExpired(ctx context.Context, id bson.ObjectID) (bool, error) {
+	filter := bson.M{
+		"_id":            id,
+		"booking_status": "confirmed",
+		"payment_status": "pending",
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"booking_status": "expired",
+			"updated_at":     time.Now(),
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to expire booking: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// FindBookingByBookingID finds a booking by its public booking ID
+func (r *BookingRepository) FindBookingByBookingID(ctx context.Context, bookingID string) (*models.Booking, error) {
+	var booking models.Booking
+	err := r.collection.FindOne(ctx, bson.M{"booking_id": bookingID}).Decode(&booking)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Booking not found
+		}
+		return nil, fmt.Errorf("failed to find booking: %w", err)
+	}
+
+	return &booking, nil
+}
+
+// FindBookingByPaymentIntent finds the booking paid with a provider payment intent,
+// either its main payment or a later charge such as an exchange price difference
+func (r *BookingRepository) FindBookingByPaymentIntent(ctx context.Context, provider, intentID string) (*models.Booking, error) {
+	// Exchanged bookings hand their payment over to the booking that replaced them
+	filter := bson.M{
+		"payment_provider": provider,
+		"$or": []bson.M{
+			{"payment_intent_id": intentID},
+			{"charges.intent_id": intentID},
+		},
+		"booking_status": bson.M{"$ne": "exchanged"},
+	}
+
+	var booking models.Booking
+	err := r.collection.FindOne(ctx, filter).Decode(&booking)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Booking not found
+		}
+		return nil, fmt.Errorf("failed to find booking: %w", err)
+	}
+
+	return &booking, nil
+}
+
+// CheckInSeats records the check-in of a booking's seats.
+// It only succeeds while the booking is valid and none of the seats was checked in yet,
+// so a ticket scanned twice at the same time is admitted once.
+func (r *BookingRepository) CheckInSeats(ctx context.Context, id bson.ObjectID, seatIDs []string, staffID string, at time.Time) (bool, error) {
+	conditions := make([]bson.M, 0, len(seatIDs))
+	for _, seatID := range seatIDs {
+		conditions = append(conditions, bson.M{
+			"seats": bson.M{"$elemMatch": bson.M{
+				"seat_id":       seatID,
+				"checked_in_at": nil,
+			}},
+		})
+	}
+
+	filter := bson.M{
+		"_id":            id,
+		"booking_status": "confirmed",
+		"payment_status": bson.M{"$in": []string{"completed", "partially_refunded"}},
+		"$and":           conditions,
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"seats.$[s].checked_in_at": at,
+			"seats.$[s].checked_in_by": staffID,
+			"updated_at":               at,
+		},
+	}
+	opts := options.UpdateOne().SetArrayFilters([]interface{}{
+		bson.M{"s.seat_id": bson.M{"$in": seatIDs}},
+	})
+
+	result, err := r.collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return false, fmt.Errorf("failed to check in seats: %w", err)
+	}
+
+	return result.MatchedCount == 1, nil
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const IdempotencyCollection = "idempotency_keys"
+
+// IdempotencyRepository handles stored responses for idempotent requests
+type IdempotencyRepository struct {
+	collection *mongo.Collection
+}
+
+// NewIdempotencyRepository creates a new IdempotencyRepository instance
+func NewIdempotencyRepository() *IdempotencyRepository {
+	return &IdempotencyRepository{
+		collection: config.GetCollection(IdempotencyCollection),
+	}
+}
+
+// EnsureIndexes makes keys unique per user and lets MongoDB purge expired records
+func (r *IdempotencyRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "user_id", Value: 1}, {Key: "key", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency indexes: %w", err)
+	}
+	return nil
+}
+
+// Reserve claims a key for a new request.
+// It returns the existing record instead when the key was already used.
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord) (*models.IdempotencyRecord, error) {
+	_, err := r.collection.InsertOne(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	var existing models.IdempotencyRecord
+	err = r.collection.FindOne(ctx, bson.M{"user_id": record.UserID, "key": record.Key}).Decode(&existing)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			// Released between our insert and lookup; let the caller retry
+			return nil, fmt.Errorf("idempotency key was released concurrently")
+		}
+		return nil, fmt.Errorf("failed to load idempotency key: %w", err)
+	}
+
+	return &existing, nil
+}
+
+// Complete stores the response for a reserved key
+func (r *IdempotencyRepository) Complete(ctx context.Context, userID, key string, statusCode int, contentType string, body []byte) error {
+	update := bson.M{
+		"$set": bson.M{
+			"status":       "completed",
+			"status_code":  statusCode,
+			"content_type": contentType,
+			"body":         body,
+		},
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"user_id": userID, "key": key}, update); err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// Release removes a reserved key so the request can be retried
+func (r *IdempotencyRepository) Release(ctx context.Context, userID, key string) error {
+	if _, err := r.collection.DeleteOne(ctx, bson.M{"user_id": userID, "key": key, "status": "processing"}); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// ReleaseStale removes a key left in processing since before the given time
+func (r *IdempotencyRepository) ReleaseStale(ctx context.Context, userID, key string, before time.Time) (bool, error) {
+	filter := bson.M{
+		"user_id":    userID,
+		"key":        key,
+		"status":     "processing",
+		"created_at": bson.M{"$lt": before},
+	}
+
+	result, err := r.collection.DeleteOne(ctx, filter)
+	if err != nil {
+		return false, fmt.Errorf("failed to release stale idempotency key: %w", err)
+	}
+	return result.DeletedCount == 1, nil
+}
@@ -0,0 +1,42 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// EnsureIndexes creates the indexes required by the repositories
+func EnsureIndexes() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := NewSessionRepository().EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	if err := NewIdempotencyRepository().EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	if err := NewBookingRepository().EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	if err := NewPaymentEventRepository().EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	if err := NewPromotionRepository().EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	if err := NewPricingRuleRepository().EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	if err := NewPriceChangeRepository().EnsureIndexes(ctx); err != nil {
+		return err
+	}
+
+	return nil
+}
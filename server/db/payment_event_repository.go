@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const PaymentEventsCollection = "payment_events"
+
+// PaymentEventRepository handles received payment webhook events
+type PaymentEventRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPaymentEventRepository creates a new PaymentEventRepository instance
+func NewPaymentEventRepository() *PaymentEventRepository {
+	return &PaymentEventRepository{
+		collection: config.GetCollection(PaymentEventsCollection),
+	}
+}
+
+// EnsureIndexes makes event IDs unique per provider
+func (r *PaymentEventRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "provider", Value: 1}, {Key: "event_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys: bson.D{{Key: "intent_id", Value: 1}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create payment event indexes: %w", err)
+	}
+	return nil
+}
+
+// Reserve records a new event.
+// It returns the existing record instead when the event was already received.
+func (r *PaymentEventRepository) Reserve(ctx context.Context, event *models.PaymentEvent) (*models.PaymentEvent, error) {
+	_, err := r.collection.InsertOne(ctx, event)
+	if err == nil {
+		return nil, nil
+	}
+	if !mongo.IsDuplicateKeyError(err) {
+		return nil, fmt.Errorf("failed to record payment event: %w", err)
+	}
+
+	var existing models.PaymentEvent
+	err = r.collection.FindOne(ctx, bson.M{"provider": event.Provider, "event_id": event.EventID}).Decode(&existing)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, fmt.Errorf("payment event was released concurrently")
+		}
+		return nil, fmt.Errorf("failed to load payment event: %w", err)
+	}
+
+	return &existing, nil
+}
+
+// Complete stores the result of processing an event
+func (r *PaymentEventRepository) Complete(ctx context.Context, provider, eventID, status, bookingID, outcome string) error {
+	now := time.Now()
+	update := bson.M{
+		"$set": bson.M{
+			"status":       status,
+			"booking_id":   bookingID,
+			"outcome":      outcome,
+			"processed_at": now,
+		},
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, bson.M{"provider": provider, "event_id": eventID}, update); err != nil {
+		return fmt.Errorf("failed to complete payment event: %w", err)
+	}
+	return nil
+}
+
+// Release forgets an event that failed to process so the provider's retry is applied
+func (r *PaymentEventRepository) Release(ctx context.Context, provider, eventID string) error {
+	filter := bson.M{"provider": provider, "event_id": eventID, "status": "processing"}
+	if _, err := r.collection.DeleteOne(ctx, filter); err != nil {
+		return fmt.Errorf("failed to release payment event: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const PriceChangesCollection = "price_changes"
+
+// PriceChangeRepository keeps the audit log of dynamic seat price changes
+type PriceChangeRepository struct {
+	collection *mongo.Collection
+}
+
+// NewPriceChangeRepository creates a new PriceChangeRepository instance
+func NewPriceChangeRepository() *PriceChangeRepository {
+	return &PriceChangeRepository{
+		collection: config.GetCollection(PriceChangesCollection),
+	}
+}
+
+// EnsureIndexes supports listing the changes of a showtime in order
+func (r *PriceChangeRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "showtime_id", Value: 1}, {Key: "created_at", Value: -1}},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create price change indexes: %w", err)
+	}
+	return nil
+}
+
+// RecordPriceChange stores a price change
+func (r *PriceChangeRepository) RecordPriceChange(ctx context.Context, change *models.PriceChange) error {
+	if _, err := r.collection.InsertOne(ctx, change); err != nil {
+		return fmt.Errorf("failed to record price change: %w", err)
+	}
+	return nil
+}
+
+// FindShowtimePriceChanges returns the latest price changes of a showtime, newest first
+func (r *PriceChangeRepository) FindShowtimePriceChanges(ctx context.Context, showtimeID bson.ObjectID, limit int64) ([]models.PriceChange, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}}).
+		SetLimit(limit)
+
+	cursor, err := r.collection.Find(ctx, bson.M{"showtime_id": showtimeID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find price changes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	changes := []models.PriceChange{}
+	if err := cursor.All(ctx, &changes); err != nil {
+		return nil, fmt.Errorf("failed to decode price changes: %w", err)
+	}
+
+	return changes, nil
+}
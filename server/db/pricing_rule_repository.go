@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const PricingRulesCollection = "pricing_rules"
+
+// PricingRuleRepository handles the fee and tax rules applied to tickets
+type PricingRuleRepository struct {
+	collection *mongo.Collection
+	theaters   *mongo.Collection
+}
+
+// NewPricingRuleRepository creates a new PricingRuleRepository instance
+func NewPricingRuleRepository() *PricingRuleRepository {
+	return &PricingRuleRepository{
+		collection: config.GetCollection(PricingRulesCollection),
+		theaters:   config.GetCollection(TheatersCollection),
+	}
+}
+
+// EnsureIndexes allows a single rule per scope, theater and state
+func (r *PricingRuleRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "scope", Value: 1}, {Key: "theater_id", Value: 1}, {Key: "state", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create pricing rule indexes: %w", err)
+	}
+	return nil
+}
+
+// SaveRule creates or replaces the rule for the rule's scope, theater and state
+func (r *PricingRuleRepository) SaveRule(ctx context.Context, rule *models.PricingRule) error {
+	filter := ruleKey(rule.Scope, rule.TheaterID, rule.State)
+
+	var existing models.PricingRule
+	err := r.collection.FindOne(ctx, filter).Decode(&existing)
+	switch {
+	case err == nil:
+		rule.ID = existing.ID
+		rule.CreatedAt = existing.CreatedAt
+	case err != mongo.ErrNoDocuments:
+		return fmt.Errorf("failed to find pricing rule: %w", err)
+	default:
+		rule.ID = bson.NewObjectID()
+	}
+
+	_, err = r.collection.ReplaceOne(ctx, bson.M{"_id": rule.ID}, rule, options.Replace().SetUpsert(true))
+	if err != nil {
+		return fmt.Errorf("failed to save pricing rule: %w", err)
+	}
+	return nil
+}
+
+// ListRules returns every configured rule
+func (r *PricingRuleRepository) ListRules(ctx context.Context) ([]models.PricingRule, error) {
+	opts := options.Find().SetSort(bson.D{{Key: "scope", Value: 1}, {Key: "state", Value: 1}})
+	cursor, err := r.collection.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find pricing rules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	rules := []models.PricingRule{}
+	if err := cursor.All(ctx, &rules); err != nil {
+		return nil, fmt.Errorf("failed to decode pricing rules: %w", err)
+	}
+
+	return rules, nil
+}
+
+// DeleteRule removes a rule, reporting false when it did not exist
+func (r *PricingRuleRepository) DeleteRule(ctx context.Context, id bson.ObjectID) (bool, error) {
+	result, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return false, fmt.Errorf("failed to delete pricing rule: %w", err)
+	}
+	return result.DeletedCount == 1, nil
+}
+
+// RuleForTheater resolves the rule that prices tickets at a theater:
+// the theater's own rule, else the rule for its state, else the default rule.
+// The built-in default is used when no default rule was configured.
+func (r *PricingRuleRepository) RuleForTheater(ctx context.Context, theaterID bson.ObjectID) (models.PricingRule, error) {
+	var theater models.Theater
+	if err := r.theaters.FindOne(ctx, bson.M{"_id": theaterID}).Decode(&theater); err != nil {
+		if err != mongo.ErrNoDocuments {
+			return models.PricingRule{}, fmt.Errorf("failed to find theater: %w", err)
+		}
+	}
+	return r.RuleFor(ctx, theaterID, theater.State)
+}
+
+// RuleFor resolves the rule for a theater in a state
+func (r *PricingRuleRepository) RuleFor(ctx context.Context, theaterID bson.ObjectID, state string) (models.PricingRule, error) {
+	candidates := []bson.M{
+		ruleKey(models.PricingScopeTheater, &theaterID, ""),
+		ruleKey(models.PricingScopeDefault, nil, ""),
+	}
+	if state = models.NormalizeState(state); state != "" {
+		candidates = append(candidates, ruleKey(models.PricingScopeState, nil, state))
+	}
+
+	cursor, err := r.collection.Find(ctx, bson.M{"$or": candidates})
+	if err != nil {
+		return models.PricingRule{}, fmt.Errorf("failed to find pricing rules: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var rules []models.PricingRule
+	if err := cursor.All(ctx, &rules); err != nil {
+		return models.PricingRule{}, fmt.Errorf("failed to decode pricing rules: %w", err)
+	}
+
+	rule := models.DefaultPricingRule
+	rank := 0
+	for _, candidate := range rules {
+		if candidateRank := pricingScopeRank(candidate.Scope); candidateRank > rank {
+			rule, rank = candidate, candidateRank
+		}
+	}
+	return rule, nil
+}
+
+// ruleKey matches the rule for a scope, theater and state
+func ruleKey(scope string, theaterID *bson.ObjectID, state string) bson.M {
+	key := bson.M{
+		"scope":      scope,
+		"theater_id": nil,
+		"state":      nil,
+	}
+	if theaterID != nil {
+		key["theater_id"] = *theaterID
+	}
+	if state != "" {
+		key["state"] = state
+	}
+	return key
+}
+
+// pricingScopeRank orders scopes so more specific rules win
+func pricingScopeRank(scope string) int {
+	switch scope {
+	case models.PricingScopeTheater:
+		return 3
+	case models.PricingScopeState:
+		return 2
+	case models.PricingScopeDefault:
+		return 1
+	}
+	return 0
+}
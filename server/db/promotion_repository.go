@@ -0,0 +1,197 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const PromotionsCollection = "promotions"
+
+// ErrPromoCodeExists is returned when creating a promotion whose code is taken
+var ErrPromoCodeExists = errors.New("promo code already exists")
+
+// PromotionRepository handles promo codes and their redemption counters
+type PromotionRepository struct {
+	collection *mongo.Collection
+	bookings   *mongo.Collection
+}
+
+// NewPromotionRepository creates a new PromotionRepository instance
+func NewPromotionRepository() *PromotionRepository {
+	return &PromotionRepository{
+		collection: config.GetCollection(PromotionsCollection),
+		bookings:   config.GetCollection(BookingsCollection),
+	}
+}
+
+// EnsureIndexes makes promo codes unique and indexes redemptions per user
+func (r *PromotionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "code", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create promotion indexes: %w", err)
+	}
+
+	_, err = r.bookings.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "promotion.promotion_id", Value: 1}, {Key: "google_user_id", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{
+			"promotion.promotion_id": bson.M{"$exists": true},
+		}),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create promotion redemption indexes: %w", err)
+	}
+	return nil
+}
+
+// CreatePromotion inserts a new promotion
+func (r *PromotionRepository) CreatePromotion(ctx context.Context, promotion *models.Promotion) error {
+	result, err := r.collection.InsertOne(ctx, promotion)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrPromoCodeExists
+		}
+		return fmt.Errorf("failed to create promotion: %w", err)
+	}
+
+	promotion.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+// FindPromotionByID finds a promotion by its ID
+func (r *PromotionRepository) FindPromotionByID(ctx context.Context, id bson.ObjectID) (*models.Promotion, error) {
+	return r.findOne(ctx, bson.M{"_id": id})
+}
+
+// FindPromotionByCode finds a promotion by its normalized code
+func (r *PromotionRepository) FindPromotionByCode(ctx context.Context, code string) (*models.Promotion, error) {
+	return r.findOne(ctx, bson.M{"code": code})
+}
+
+func (r *PromotionRepository) findOne(ctx context.Context, filter bson.M) (*models.Promotion, error) {
+	var promotion models.Promotion
+	err := r.collection.FindOne(ctx, filter).Decode(&promotion)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Promotion not found
+		}
+		return nil, fmt.Errorf("failed to find promotion: %w", err)
+	}
+
+	return &promotion, nil
+}
+
+// ListPromotions returns every promotion, newest first
+func (r *PromotionRepository) ListPromotions(ctx context.Context) ([]models.Promotion, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{}, options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find promotions: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	promotions := []models.Promotion{}
+	if err := cursor.All(ctx, &promotions); err != nil {
+		return nil, fmt.Errorf("failed to decode promotions: %w", err)
+	}
+
+	return promotions, nil
+}
+
+// UpdatePromotion replaces a promotion's rules, keeping its code, counter and creation details
+func (r *PromotionRepository) UpdatePromotion(ctx context.Context, promotion *models.Promotion) (bool, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"description":    promotion.Description,
+			"discount_type":  promotion.DiscountType,
+			"discount_value": promotion.DiscountValue,
+			"max_discount":   promotion.MaxDiscount,
+			"min_seats":      promotion.MinSeats,
+			"min_amount":     promotion.MinAmount,
+			"usage_limit":    promotion.UsageLimit,
+			"per_user_limit": promotion.PerUserLimit,
+			"valid_from":     promotion.ValidFrom,
+			"valid_until":    promotion.ValidUntil,
+			"movie_ids":      promotion.MovieIDs,
+			"theater_ids":    promotion.TheaterIDs,
+			"formats":        promotion.Formats,
+			"is_active":      promotion.IsActive,
+			"updated_at":     promotion.UpdatedAt,
+		},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": promotion.ID}, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to update promotion: %w", err)
+	}
+
+	return result.MatchedCount == 1, nil
+}
+
+// CountUserRedemptions counts the active bookings of a user that redeemed a promotion
+func (r *PromotionRepository) CountUserRedemptions(ctx context.Context, promotionID bson.ObjectID, ownerIDs []string) (int64, error) {
+	count, err := r.bookings.CountDocuments(ctx, bson.M{
+		"promotion.promotion_id": promotionID,
+		"google_user_id":         bson.M{"$in": ownerIDs},
+		"booking_status":         "confirmed",
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to count promotion redemptions: %w", err)
+	}
+
+	return count, nil
+}
+
+// Redeem takes one use of a promotion.
+// It reports false when the promotion is inactive, outside its validity window or used up.
+// Every redemption writes the promotion document, so concurrent booking transactions
+// redeeming the same code conflict and are retried rather than overshooting the limits.
+func (r *PromotionRepository) Redeem(ctx context.Context, id bson.ObjectID, now time.Time) (bool, error) {
+	filter := bson.M{
+		"_id":         id,
+		"is_active":   true,
+		"valid_from":  bson.M{"$lte": now},
+		"valid_until": bson.M{"$gt": now},
+		"$or": []bson.M{
+			{"usage_limit": 0},
+			{"$expr": bson.M{"$lt": []string{"$used_count", "$usage_limit"}}},
+		},
+	}
+	update := bson.M{
+		"$inc": bson.M{"used_count": 1},
+		"$set": bson.M{"updated_at": now},
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to redeem promotion: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
+
+// Release returns a use taken by a booking that was cancelled or expired
+func (r *PromotionRepository) Release(ctx context.Context, id bson.ObjectID) error {
+	filter := bson.M{
+		"_id":        id,
+		"used_count": bson.M{"$gt": 0},
+	}
+	update := bson.M{
+		"$inc": bson.M{"used_count": -1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to release promotion: %w", err)
+	}
+	return nil
+}
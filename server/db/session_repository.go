@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const SessionsCollection = "sessions"
+
+// SessionRepository handles session database operations
+type SessionRepository struct {
+	collection *mongo.Collection
+}
+
+// NewSessionRepository creates a new SessionRepository instance
+func NewSessionRepository() *SessionRepository {
+	return &SessionRepository{
+		collection: config.GetCollection(SessionsCollection),
+	}
+}
+
+// EnsureIndexes creates the lookup index and lets MongoDB purge expired sessions
+func (r *SessionRepository) EnsureIndexes(ctx context.Context) error {
+	_, err := r.collection.Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "session_id", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "expires_at", Value: 1}},
+			Options: options.Index().SetExpireAfterSeconds(0),
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session indexes: %w", err)
+	}
+	return nil
+}
+
+// CreateSession stores a new session
+func (r *SessionRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	result, err := r.collection.InsertOne(ctx, session)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	session.ID = result.InsertedID.(bson.ObjectID)
+	return nil
+}
+
+// FindSessionByID finds a session by its random session identifier
+func (r *SessionRepository) FindSessionByID(ctx context.Context, sessionID string) (*models.Session, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	var session models.Session
+	err := r.collection.FindOne(ctx, bson.M{"session_id": sessionID}).Decode(&session)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Session not found
+		}
+		return nil, fmt.Errorf("failed to find session: %w", err)
+	}
+
+	return &session, nil
+}
+
+// RevokeSession marks a session as revoked so its token is no longer accepted
+func (r *SessionRepository) RevokeSession(ctx context.Context, sessionID string) error {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	filter := bson.M{"session_id": sessionID, "revoked_at": bson.M{"$exists": false}}
+	update := bson.M{"$set": bson.M{"revoked_at": time.Now()}}
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
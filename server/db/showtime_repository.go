@@ -0,0 +1,355 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const ShowtimesCollection = "showtimes"
+
+var ErrSeatsUnavailable = errors.New("one or more seats are not available")
+
+// ShowtimeRepository handles seat-level showtime updates.
+// Every seat transition is a conditional update on a single seat so that
+// concurrent requests and the hold reaper can never overwrite each other.
+type ShowtimeRepository struct {
+	collection *mongo.Collection
+}
+
+// NewShowtimeRepository creates a new ShowtimeRepository instance
+func NewShowtimeRepository() *ShowtimeRepository {
+	return &ShowtimeRepository{
+		collection: config.GetCollection(ShowtimesCollection),
+	}
+}
+
+// HoldSeats atomically holds all requested seats for a booking, or none of them.
+// The update only matches while every seat is still available, so two concurrent
+// requests for the same seat can never both succeed.
+func (r *ShowtimeRepository) HoldSeats(ctx context.Context, showtimeID bson.ObjectID, seatIDs []string, userID, bookingID string, until time.Time) error {
+	return r.claimSeats(ctx, showtimeID, seatIDs, bson.M{
+		"seats.$[s].status":          models.SeatHeld,
+		"seats.$[s].booked_by":       userID,
+		"seats.$[s].held_for":        bookingID,
+		"seats.$[s].hold_expires_at": until,
+	}, true)
+}
+
+// BookSeats atomically books available seats directly, bypassing the payment hold
+func (r *ShowtimeRepository) BookSeats(ctx context.Context, showtimeID bson.ObjectID, seatIDs []string, userID string) error {
+	return r.claimSeats(ctx, showtimeID, seatIDs, bson.M{
+		"seats.$[s].status":    models.SeatBooked,
+		"seats.$[s].booked_by": userID,
+	}, true)
+}
+
+// BlockSeats atomically blocks available seats until the given time
+func (r *ShowtimeRepository) BlockSeats(ctx context.Context, showtimeID bson.ObjectID, seatIDs []string, until time.Time) error {
+	return r.claimSeats(ctx, showtimeID, seatIDs, bson.M{
+		"seats.$[s].status":          models.SeatBlocked,
+		"seats.$[s].blocked_at":      time.Now(),
+		"seats.$[s].hold_expires_at": until,
+	}, false)
+}
+
+// ReleaseSeats releases held, booked or blocked seats regardless of who holds them.
+// It returns the number of seats that were actually released.
+func (r *ShowtimeRepository) ReleaseSeats(ctx context.Context, showtimeID bson.ObjectID, seatIDs []string) (int, error) {
+	released := 0
+	for _, seatID := range models.UniqueSeatIDs(seatIDs) {
+		for _, status := range []string{models.SeatHeld, models.SeatBooked, models.SeatBlocked} {
+			filter := bson.M{
+				"_id": showtimeID,
+				"seats": bson.M{"$elemMatch": bson.M{
+					"seat_id": seatID,
+					"status":  status,
+				}},
+			}
+
+			delta := -1
+			if status == models.SeatBlocked {
+				delta = 0
+			}
+
+			ok, err := r.releaseSeat(ctx, filter, delta)
+			if err != nil {
+				return released, err
+			}
+			if ok {
+				released++
+				break
+			}
+		}
+	}
+	return released, nil
+}
+
+// ReleaseBookingSeats releases the held or booked seats belonging to a booking.
+// Seats booked before holds recorded their booking are matched as well.
+// It returns the number of seats that were actually released.
+func (r *ShowtimeRepository) ReleaseBookingSeats(ctx context.Context, showtimeID bson.ObjectID, seatIDs []string, bookingID string) (int, error) {
+	released := 0
+	for _, seatID := range models.UniqueSeatIDs(seatIDs) {
+		filter := bson.M{
+			"_id": showtimeID,
+			"seats": bson.M{"$elemMatch": bson.M{
+				"seat_id":  seatID,
+				"status":   bson.M{"$in": []string{models.SeatHeld, models.SeatBooked}},
+				"held_for": bson.M{"$in": []interface{}{bookingID, nil}},
+			}},
+		}
+
+		ok, err := r.releaseSeat(ctx, filter, -1)
+		if err != nil {
+			return released, err
+		}
+		if ok {
+			released++
+		}
+	}
+	return released, nil
+}
+
+// ConfirmHeldSeats turns the seats held for a booking into booked seats
+func (r *ShowtimeRepository) ConfirmHeldSeats(ctx context.Context, showtimeID bson.ObjectID, seatIDs []string, bookingID string) error {
+	filter := bson.M{"_id": showtimeID}
+	update := bson.M{
+		"$set": bson.M{
+			"seats.$[s].status": models.SeatBooked,
+			"updated_at":        time.Now(),
+		},
+		"$unset": bson.M{
+			"seats.$[s].hold_expires_at": "",
+		},
+	}
+	opts := options.UpdateOne().SetArrayFilters([]interface{}{
+		bson.M{
+			"s.seat_id":  bson.M{"$in": seatIDs},
+			"s.status":   models.SeatHeld,
+			"s.held_for": bookingID,
+		},
+	})
+
+	if _, err := r.collection.UpdateOne(ctx, filter, update, opts); err != nil {
+		return fmt.Errorf("failed to confirm held seats: %w", err)
+	}
+	return nil
+}
+
+// ReleaseHeldSeat releases a single seat held for a booking.
+// It reports whether the seat was actually released so booked_seats stays exact.
+func (r *ShowtimeRepository) ReleaseHeldSeat(ctx context.Context, showtimeID bson.ObjectID, seatID, bookingID string) (bool, error) {
+	filter := bson.M{
+		"_id": showtimeID,
+		"seats": bson.M{"$elemMatch": bson.M{
+			"seat_id":  seatID,
+			"status":   models.SeatHeld,
+			"held_for": bookingID,
+		}},
+	}
+
+	return r.releaseSeat(ctx, filter, -1)
+}
+
+// ReleaseExpiredHold releases a held or blocked seat whose hold lapsed before the given time
+func (r *ShowtimeRepository) ReleaseExpiredHold(ctx context.Context, showtimeID bson.ObjectID, seat models.Seat, before time.Time) (bool, error) {
+	filter := bson.M{
+		"_id": showtimeID,
+		"seats": bson.M{"$elemMatch": bson.M{
+			"seat_id":         seat.SeatID,
+			"status":          seat.Status,
+			"hold_expires_at": bson.M{"$lt": before},
+		}},
+	}
+
+	// Blocked seats never counted towards booked_seats
+	delta := 0
+	if seat.Status == models.SeatHeld {
+		delta = -1
+	}
+
+	return r.releaseSeat(ctx, filter, delta)
+}
+
+// FindShowtimesWithExpiredHolds returns showtimes having held or blocked seats that lapsed before the given time
+func (r *ShowtimeRepository) FindShowtimesWithExpiredHolds(ctx context.Context, before time.Time) ([]models.Showtime, error) {
+	filter := bson.M{
+		"seats": bson.M{"$elemMatch": bson.M{
+			"status":          bson.M{"$in": []string{models.SeatHeld, models.SeatBlocked}},
+			"hold_expires_at": bson.M{"$lt": before},
+		}},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find showtimes with expired holds: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var showtimes []models.Showtime
+	if err := cursor.All(ctx, &showtimes); err != nil {
+		return nil, fmt.Errorf("failed to decode showtimes: %w", err)
+	}
+
+	return showtimes, nil
+}
+
+// FindShowtimeByID finds a showtime by its ID
+func (r *ShowtimeRepository) FindShowtimeByID(ctx context.Context, id bson.ObjectID) (*models.Showtime, error) {
+	var showtime models.Showtime
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&showtime)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Showtime not found
+		}
+		return nil, fmt.Errorf("failed to find showtime: %w", err)
+	}
+
+	return &showtime, nil
+}
+
+// FindUpcomingShowtimeIDs returns the IDs of a theater's active showtimes that have not started
+func (r *ShowtimeRepository) FindUpcomingShowtimeIDs(ctx context.Context, theaterID bson.ObjectID, after time.Time) ([]bson.ObjectID, error) {
+	filter := bson.M{
+		"theater_id": theaterID,
+		"status":     "active",
+		"show_time":  bson.M{"$gt": after},
+	}
+
+	cursor, err := r.collection.Find(ctx, filter, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find upcoming showtimes: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var showtimes []struct {
+		ID bson.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &showtimes); err != nil {
+		return nil, fmt.Errorf("failed to decode showtimes: %w", err)
+	}
+
+	ids := make([]bson.ObjectID, 0, len(showtimes))
+	for _, showtime := range showtimes {
+		ids = append(ids, showtime.ID)
+	}
+	return ids, nil
+}
+
+// ApplyPriceChange sets the new price of every available seat listed in the change
+// and records the change on the showtime. Held and booked seats keep the price they were sold at.
+func (r *ShowtimeRepository) ApplyPriceChange(ctx context.Context, change *models.PriceChange, pricing models.ShowPricing) error {
+	set := bson.M{
+		"price_multiplier":  change.Multiplier,
+		"price_change_id":   change.ID,
+		"prices_updated_at": change.CreatedAt,
+		"pricing":           pricing,
+		"updated_at":        change.CreatedAt,
+	}
+	arrayFilters := make([]interface{}, 0, len(change.Prices))
+	for i, price := range change.Prices {
+		name := fmt.Sprintf("p%d", i)
+		set["seats.$["+name+"].price"] = price.Price
+		set["seats.$["+name+"].base_price"] = price.BasePrice
+		arrayFilters = append(arrayFilters, bson.M{
+			name + ".seat_id": bson.M{"$in": price.SeatIDs},
+			name + ".status":  models.SeatAvailable,
+		})
+	}
+
+	opts := options.UpdateOne()
+	if len(arrayFilters) > 0 {
+		opts.SetArrayFilters(arrayFilters)
+	}
+
+	result, err := r.collection.UpdateOne(ctx, bson.M{"_id": change.ShowtimeID}, bson.M{"$set": set}, opts)
+	if err != nil {
+		return fmt.Errorf("failed to update seat prices: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return fmt.Errorf("showtime %s not found", change.ShowtimeID.Hex())
+	}
+	return nil
+}
+
+// claimSeats applies seatFields to every requested seat, but only if all of them are available.
+// When countAsBooked is set, booked_seats grows by the number of claimed seats in the same update.
+func (r *ShowtimeRepository) claimSeats(ctx context.Context, showtimeID bson.ObjectID, seatIDs []string, seatFields bson.M, countAsBooked bool) error {
+	seatIDs = models.UniqueSeatIDs(seatIDs)
+	if len(seatIDs) == 0 {
+		return ErrSeatsUnavailable
+	}
+
+	conditions := make([]bson.M, 0, len(seatIDs))
+	for _, seatID := range seatIDs {
+		conditions = append(conditions, bson.M{
+			"seats": bson.M{"$elemMatch": bson.M{
+				"seat_id": seatID,
+				"status":  models.SeatAvailable,
+			}},
+		})
+	}
+
+	filter := bson.M{
+		"_id":    showtimeID,
+		"status": "active",
+		"$and":   conditions,
+	}
+
+	set := bson.M{"updated_at": time.Now()}
+	for field, value := range seatFields {
+		set[field] = value
+	}
+	update := bson.M{"$set": set}
+	if countAsBooked {
+		update["$inc"] = bson.M{"booked_seats": len(seatIDs)}
+	}
+
+	opts := options.UpdateOne().SetArrayFilters([]interface{}{
+		bson.M{"s.seat_id": bson.M{"$in": seatIDs}},
+	})
+
+	result, err := r.collection.UpdateOne(ctx, filter, update, opts)
+	if err != nil {
+		return fmt.Errorf("failed to reserve seats: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return ErrSeatsUnavailable
+	}
+
+	return nil
+}
+
+// releaseSeat makes the seat matched by the positional filter available again
+func (r *ShowtimeRepository) releaseSeat(ctx context.Context, filter bson.M, bookedDelta int) (bool, error) {
+	update := bson.M{
+		"$set": bson.M{
+			"seats.$.status": models.SeatAvailable,
+			"updated_at":     time.Now(),
+		},
+		"$unset": bson.M{
+			"seats.$.booked_by":       "",
+			"seats.$.blocked_at":      "",
+			"seats.$.held_for":        "",
+			"seats.$.hold_expires_at": "",
+		},
+	}
+	if bookedDelta != 0 {
+		update["$inc"] = bson.M{"booked_seats": bookedDelta}
+	}
+
+	result, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return false, fmt.Errorf("failed to release seat: %w", err)
+	}
+
+	return result.ModifiedCount == 1, nil
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/mongotest"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+func TestClaimSeatsConcurrently(t *testing.T) {
+	mongotest.Setup(t)
+
+	const seatCount = 20
+	const contenders = 5 // Claims of each shape per seat
+
+	showtime := models.Showtime{ID: bson.NewObjectID(), Status: "active", TotalSeats: seatCount}
+	for i := 1; i <= seatCount; i++ {
+		showtime.Seats = append(showtime.Seats, models.Seat{
+			SeatID:     fmt.Sprintf("A%d", i),
+			RowID:      "A",
+			SeatNumber: i,
+			SeatType:   "regular",
+			Status:     models.SeatAvailable,
+			Price:      100,
+		})
+	}
+
+	ctx := context.Background()
+	repo := NewShowtimeRepository()
+	if _, err := repo.collection.InsertOne(ctx, showtime); err != nil {
+		t.Fatalf("inserting showtime: %v", err)
+	}
+
+	// Every seat is wanted on its own and together with its neighbour, both as
+	// a payment hold and as a direct booking, all at once
+	type claim struct {
+		owner   string
+		seatIDs []string
+		hold    bool
+	}
+	var claims []claim
+	for i := 1; i <= seatCount; i++ {
+		for n := 0; n < contenders; n++ {
+			single := []string{fmt.Sprintf("A%d", i)}
+			claims = append(claims, claim{fmt.Sprintf("single-%d-%d", i, n), single, n%2 == 0})
+			if i < seatCount {
+				pair := []string{fmt.Sprintf("A%d", i), fmt.Sprintf("A%d", i+1)}
+				claims = append(claims, claim{fmt.Sprintf("pair-%d-%d", i, n), pair, n%2 == 1})
+			}
+		}
+	}
+
+	var (
+		mu      sync.Mutex
+		winners = make(map[string][]string) // Seat ID to the owners that claimed it
+		wg      sync.WaitGroup
+		start   = make(chan struct{})
+	)
+	for _, cl := range claims {
+		wg.Add(1)
+		go func(cl claim) {
+			defer wg.Done()
+			<-start
+
+			session, err := config.MongoClient.StartSession()
+			if err != nil {
+				t.Errorf("%s: starting session: %v", cl.owner, err)
+				return
+			}
+			defer session.EndSession(ctx)
+
+			_, err = session.WithTransaction(ctx, func(sc context.Context) (interface{}, error) {
+				if cl.hold {
+					return nil, repo.HoldSeats(sc, showtime.ID, cl.seatIDs, cl.owner, cl.owner, time.Now().Add(10*time.Minute))
+				}
+				return nil, repo.BookSeats(sc, showtime.ID, cl.seatIDs, cl.owner)
+			})
+			switch {
+			case err == nil:
+				mu.Lock()
+				for _, seatID := range cl.seatIDs {
+					winners[seatID] = append(winners[seatID], cl.owner)
+				}
+				mu.Unlock()
+			case !errors.Is(err, ErrSeatsUnavailable):
+				t.Errorf("%s: %v", cl.owner, err)
+			}
+		}(cl)
+	}
+	close(start)
+	wg.Wait()
+
+	var stored models.Showtime
+	if err := repo.collection.FindOne(ctx, bson.M{"_id": showtime.ID}).Decode(&stored); err != nil {
+		t.Fatalf("reloading showtime: %v", err)
+	}
+
+	// Single-seat contenders only lose once their seat is taken, so every seat ends up claimed exactly once
+	for _, seat := range stored.Seats {
+		owners := winners[seat.SeatID]
+		if len(owners) != 1 {
+			t.Errorf("seat %s claimed by %v, want exactly one winner", seat.SeatID, owners)
+			continue
+		}
+		if seat.BookedBy != owners[0] {
+			t.Errorf("seat %s stored for %q, won by %q", seat.SeatID, seat.BookedBy, owners[0])
+		}
+		if seat.Status != models.SeatHeld && seat.Status != models.SeatBooked {
+			t.Errorf("seat %s is %s after being claimed", seat.SeatID, seat.Status)
+		}
+	}
+	if stored.BookedSeats != seatCount {
+		t.Errorf("booked_seats %d, want %d", stored.BookedSeats, seatCount)
+	}
+}
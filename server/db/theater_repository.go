@@ -0,0 +1,55 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const TheatersCollection = "theaters"
+
+// TheaterRepository handles theater lookups used outside of the HTTP handlers
+type TheaterRepository struct {
+	collection *mongo.Collection
+}
+
+// NewTheaterRepository creates a new TheaterRepository instance
+func NewTheaterRepository() *TheaterRepository {
+	return &TheaterRepository{
+		collection: config.GetCollection(TheatersCollection),
+	}
+}
+
+// FindTheaterByID finds a theater by its ID
+func (r *TheaterRepository) FindTheaterByID(ctx context.Context, id bson.ObjectID) (*models.Theater, error) {
+	var theater models.Theater
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&theater)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // Theater not found
+		}
+		return nil, fmt.Errorf("failed to find theater: %w", err)
+	}
+
+	return &theater, nil
+}
+
+// FindDynamicPricingTheaters returns the theaters that have dynamic pricing enabled
+func (r *TheaterRepository) FindDynamicPricingTheaters(ctx context.Context) ([]models.Theater, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"dynamic_pricing.enabled": true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find theaters: %w", err)
+	}
+	defer cursor.Close(ctx)
+
+	var theaters []models.Theater
+	if err := cursor.All(ctx, &theaters); err != nil {
+		return nil, fmt.Errorf("failed to decode theaters: %w", err)
+	}
+
+	return theaters, nil
+}
@@ -54,6 +54,7 @@ func (r *UserRepository) UpsertUser(ctx context.Context, user *models.User) (*mo
 		},
 		"$setOnInsert": bson.M{
 			"google_id":  user.GoogleID,
+			"role":       models.RoleCustomer,
 			"created_at": user.CreatedAt,
 		},
 	}
@@ -132,3 +133,66 @@ func (r *UserRepository) FindUserByID(ctx context.Context, id string) (*models.U
 
 	return &user, nil
 }
+
+// UpdateUserRole sets a user's role and the theaters they manage
+func (r *UserRepository) UpdateUserRole(ctx context.Context, id bson.ObjectID, role string, managedTheaterIDs []bson.ObjectID) (*models.User, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if managedTheaterIDs == nil {
+		managedTheaterIDs = []bson.ObjectID{}
+	}
+
+	update := bson.M{
+		"$set": bson.M{
+			"role":                role,
+			"managed_theater_ids": managedTheaterIDs,
+			"updated_at":          time.Now(),
+		},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // User not found
+		}
+		return nil, fmt.Errorf("failed to update user role: %w", err)
+	}
+
+	return &user, nil
+}
+
+// ResetCalendarFeed bumps the user's calendar feed version, revoking every feed URL issued before
+func (r *UserRepository) ResetCalendarFeed(ctx context.Context, id bson.ObjectID) (*models.User, error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	update := bson.M{
+		"$inc": bson.M{"calendar_feed_version": 1},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var user models.User
+	err := r.collection.FindOneAndUpdate(ctx, bson.M{"_id": id}, update, opts).Decode(&user)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil // User not found
+		}
+		return nil, fmt.Errorf("failed to reset calendar feed: %w", err)
+	}
+
+	return &user, nil
+}
@@ -3,8 +3,10 @@ module github.com/tejas161/Cinema-Flix
 go 1.24.4
 
 require (
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/gofiber/fiber/v2 v2.52.9
 	github.com/joho/godotenv v1.5.1
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 	go.mongodb.org/mongo-driver/v2 v2.3.0
 	golang.org/x/oauth2 v0.30.0
 )
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// IsPlatformAdminEmail checks if an email is listed in PLATFORM_ADMIN_EMAILS.
+// Listed users are promoted to platform_admin when they sign in, which
+// bootstraps the first administrator of a fresh deployment.
+func IsPlatformAdminEmail(email string) bool {
+	if email == "" {
+		return false
+	}
+
+	for _, admin := range strings.Split(os.Getenv("PLATFORM_ADMIN_EMAILS"), ",") {
+		if strings.EqualFold(strings.TrimSpace(admin), email) {
+			return true
+		}
+	}
+	return false
+}
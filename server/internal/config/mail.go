@@ -0,0 +1,48 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+)
+
+type MailConfig struct {
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	ClientURL string
+}
+
+// NewMailConfig creates and returns the outgoing mail configuration.
+// Without SMTP_HOST emails are only logged, which keeps local setups working.
+func NewMailConfig() *MailConfig {
+	port := 587
+	if value := os.Getenv("SMTP_PORT"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			log.Fatalf("Invalid SMTP_PORT %q: %v", value, err)
+		}
+		port = parsed
+	}
+
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "Cinema-Flix <no-reply@cinemaflix.local>"
+	}
+
+	clientURL := os.Getenv("CLIENT_URL")
+	if clientURL == "" {
+		clientURL = "http://localhost:3000"
+	}
+
+	return &MailConfig{
+		Host:      os.Getenv("SMTP_HOST"),
+		Port:      port,
+		Username:  os.Getenv("SMTP_USERNAME"),
+		Password:  os.Getenv("SMTP_PASSWORD"),
+		From:      from,
+		ClientURL: clientURL,
+	}
+}
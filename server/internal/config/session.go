@@ -0,0 +1,42 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+type SessionConfig struct {
+	Secret       []byte
+	TTL          time.Duration
+	CookieName   string
+	CookieSecure bool
+}
+
+// NewSessionConfig creates and returns the session configuration
+func NewSessionConfig() *SessionConfig {
+	secret := os.Getenv("SESSION_SECRET")
+	if len(secret) < 32 {
+		log.Fatal("SESSION_SECRET environment variable must be set to at least 32 characters. Please add it to your .env file.")
+	}
+
+	ttl := 7 * 24 * time.Hour
+	if value := os.Getenv("SESSION_TTL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			log.Fatalf("Invalid SESSION_TTL %q: %v", value, err)
+		}
+		ttl = parsed
+	}
+
+	// Only mark cookies Secure when the API itself is served over HTTPS
+	secure := strings.HasPrefix(os.Getenv("redirectURL"), "https://")
+
+	return &SessionConfig{
+		Secret:       []byte(secret),
+		TTL:          ttl,
+		CookieName:   "cinemaflix_session",
+		CookieSecure: secure,
+	}
+}
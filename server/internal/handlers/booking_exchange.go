@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/internal/services/notifications"
+	"github.com/tejas161/Cinema-Flix/internal/services/payments"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+var errBookingChanged = errors.New("booking changed during the exchange, please try again")
+
+// ExchangeBooking moves a paid booking to other seats or another showtime of the same movie.
+// A new booking replaces the original one. Its payment is carried over: a higher price is
+// charged before the exchange commits and a lower price is refunded afterwards.
+func (h *BookingsHandler) ExchangeBooking() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bookingID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid booking ID",
+			})
+		}
+
+		var request struct {
+			ShowtimeID string   `json:"showtime_id"`
+			SeatIDs    []string `json:"seat_ids"`
+		}
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid request body",
+			})
+		}
+
+		seatIDs := models.UniqueSeatIDs(request.SeatIDs)
+		if len(seatIDs) == 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "At least one seat must be selected",
+			})
+		}
+
+		user := middleware.CurrentUser(c)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		// Load the original booking
+		var original models.Booking
+		err = h.bookingsCollection.FindOne(ctx, bson.M{"_id": bookingID}).Decode(&original)
+		if err != nil || !canAccessBooking(user, &original) {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Booking not found",
+			})
+		}
+		if err := checkExchangeable(&original); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+
+		// The target defaults to the booking's own showtime, for a change of seats
+		targetID := original.ShowtimeID
+		if request.ShowtimeID != "" {
+			targetID, err = bson.ObjectIDFromHex(request.ShowtimeID)
+			if err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"success": false,
+					"error":   "Invalid showtime ID",
+				})
+			}
+		}
+
+		var target models.Showtime
+		if err := h.showtimesCollection.FindOne(ctx, bson.M{"_id": targetID}).Decode(&target); err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Showtime not found",
+			})
+		}
+
+		rule, err := h.pricingRuleRepo.RuleForTheater(ctx, target.TheaterID)
+		if err != nil {
+			log.Printf("Error resolving pricing rule: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to price exchange",
+			})
+		}
+
+		// The promo code is checked again for the new showtime and seats
+		var promotion *models.Promotion
+		if original.Promotion != nil {
+			promotion, err = h.promotionRepo.FindPromotionByID(ctx, original.Promotion.PromotionID)
+			if err != nil {
+				log.Printf("Error fetching promotion: %v", err)
+				return c.Status(500).JSON(fiber.Map{
+					"success": false,
+					"error":   "Failed to price exchange",
+				})
+			}
+		}
+
+		replacement, err := h.buildExchangeBooking(&original, &target, seatIDs, rule, promotion)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+
+		// Charge a price increase up front so the exchange never commits unpaid
+		credit := original.Pricing.PaidAmount - original.RefundedAmount()
+		difference := payments.ToMinorUnits(replacement.Pricing.TotalAmount) - payments.ToMinorUnits(credit)
+		var charge *payments.Intent
+		if difference > 0 {
+			charge, err = h.chargeExchangeDifference(ctx, &original, replacement, difference)
+			if err != nil {
+				return h.paymentErrorResponse(c, err)
+			}
+			replacement.Charges = append(replacement.Charges, capturedCharge(charge))
+			replacement.Pricing.PaidAmount = credit + payments.FromMinorUnits(charge.CapturedAmount)
+		}
+
+		var refund *models.Refund
+		if difference < 0 {
+			now := time.Now()
+			refund = &models.Refund{
+				ID:        bson.NewObjectID(),
+				Amount:    payments.FromMinorUnits(-difference),
+				Percent:   100,
+				Reason:    "exchange_difference",
+				Status:    models.RefundPending,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			replacement.Refunds = []models.Refund{*refund}
+		}
+
+		session, err := config.MongoClient.StartSession()
+		if err != nil {
+			log.Printf("Error starting session: %v", err)
+			if charge != nil {
+				h.refundCapturedIntent(ctx, charge, "exchange failed")
+			}
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to start exchange process",
+			})
+		}
+		defer session.EndSession(ctx)
+
+		// Swap the seats and the bookings in one transaction
+		exchange := func(sc context.Context) (interface{}, error) {
+			// Retire the original booking, provided nothing changed since it was read
+			result, err := h.bookingsCollection.UpdateOne(sc, bson.M{
+				"_id":            original.ID,
+				"booking_status": "confirmed",
+				"updated_at":     original.UpdatedAt,
+			}, bson.M{
+				"$set": bson.M{
+					"booking_status": "exchanged",
+					"exchanged_to":   replacement.BookingID,
+					"updated_at":     time.Now(),
+				},
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to update booking: %v", err)
+			}
+			if result.ModifiedCount == 0 {
+				return nil, errBookingChanged
+			}
+
+			// A promo code that does not apply to the replacement has its use given back
+			if original.Promotion != nil && replacement.Promotion == nil {
+				if err := h.promotionRepo.Release(sc, original.Promotion.PromotionID); err != nil {
+					return nil, err
+				}
+			}
+
+			// Release the old seats first so seats can be kept when only some change
+			if _, err := h.showtimeRepo.ReleaseBookingSeats(sc, original.ShowtimeID, bookedSeatIDs(original.Seats), original.BookingID); err != nil {
+				return nil, fmt.Errorf("failed to release seats: %v", err)
+			}
+
+			// Claim the new seats for the replacement and book them straight away
+			if err := h.showtimeRepo.HoldSeats(sc, target.ID, seatIDs, user.ID.Hex(), replacement.BookingID, replacement.ExpiresAt); err != nil {
+				return nil, err
+			}
+			if err := h.showtimeRepo.ConfirmHeldSeats(sc, target.ID, seatIDs, replacement.BookingID); err != nil {
+				return nil, err
+			}
+
+			insert, err := h.bookingsCollection.InsertOne(sc, replacement)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create booking: %w", err)
+			}
+			replacement.ID = insert.InsertedID.(bson.ObjectID)
+
+			return nil, nil
+		}
+
+		// Start over with a new booking ID if the generated one is taken
+		for attempt := 1; ; attempt++ {
+			_, err = session.WithTransaction(ctx, exchange)
+			if !mongo.IsDuplicateKeyError(err) || attempt == bookingIDAttempts {
+				break
+			}
+			log.Printf("Booking ID collision, retrying (attempt %d)", attempt)
+			replacement.BookingID = h.generateBookingID()
+		}
+
+		if err != nil {
+			log.Printf("Exchange transaction failed: %v", err)
+			if charge != nil {
+				h.refundCapturedIntent(ctx, charge, "exchange failed")
+			}
+			switch {
+			case errors.Is(err, db.ErrSeatsUnavailable):
+				return c.Status(409).JSON(fiber.Map{
+					"success": false,
+					"error":   "One or more selected seats were just taken. Please choose different seats.",
+				})
+			case errors.Is(err, errBookingChanged):
+				return c.Status(409).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			default:
+				return c.Status(400).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			}
+		}
+
+		// Return a price decrease once the exchange is committed
+		if refund != nil {
+			h.issueRefund(ctx, replacement, refund)
+		}
+
+		// The replacement comes with a new ticket
+		h.notify(notifications.EmailPaymentConfirmed, replacement, nil)
+		h.reprice(replacement.ShowtimeID)
+
+		return c.Status(201).JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"booking":        replacement,
+				"exchanged_from": original.BookingID,
+				"charged":        payments.FromMinorUnits(max(difference, 0)),
+				"refund":         refund,
+				"message":        "Booking exchanged successfully",
+			},
+		})
+	}
+}
+
+// checkExchangeable reports why a booking cannot be exchanged
+func checkExchangeable(booking *models.Booking) error {
+	if booking.BookingStatus != "confirmed" {
+		return fmt.Errorf("only confirmed bookings can be exchanged")
+	}
+	if booking.PaymentStatus != "completed" && booking.PaymentStatus != "partially_refunded" {
+		return fmt.Errorf("only paid bookings can be exchanged; cancel unpaid bookings and book again")
+	}
+	if !booking.ShowTime.After(time.Now()) {
+		return fmt.Errorf("the show has already started")
+	}
+	return nil
+}
+
+// buildExchangeBooking prepares the booking that replaces original on the target showtime,
+// priced with the target theater's current rule. promotion is the original's promotion, if
+// it still exists.
+func (h *BookingsHandler) buildExchangeBooking(original *models.Booking, target *models.Showtime, seatIDs []string, rule models.PricingRule, promotion *models.Promotion) (*models.Booking, error) {
+	if target.MovieID != original.MovieID {
+		return nil, fmt.Errorf("bookings can only be exchanged for a showtime of the same movie")
+	}
+	if !target.IsAvailable() {
+		return nil, fmt.Errorf("showtime is not available for booking")
+	}
+
+	// Seats of the original booking may be kept when staying on the same showtime
+	ownSeats := make(map[string]bool)
+	if target.ID == original.ShowtimeID {
+		for _, seat := range original.Seats {
+			ownSeats[seat.SeatID] = true
+		}
+	}
+
+	replacement := models.NewBooking(
+		h.generateBookingID(),
+		models.GoogleUserInfo{
+			GoogleID: original.GoogleUserID,
+			Email:    original.UserEmail,
+			Name:     original.UserName,
+			Picture:  original.UserPicture,
+		},
+		target.ID,
+		target.MovieID,
+		target.TheaterID,
+		target.ScreenID,
+		target.ShowDate,
+		target.ShowTime,
+	)
+	replacement.PriceMultiplier = target.PriceMultiplier
+	replacement.PriceChangeID = target.PriceChangeID
+
+	for _, seatID := range seatIDs {
+		seat := target.GetSeatByID(seatID)
+		if seat == nil {
+			return nil, fmt.Errorf("seat %s not found", seatID)
+		}
+		if seat.Status != models.SeatAvailable && !ownSeats[seatID] {
+			return nil, fmt.Errorf("seat %s is not available", seatID)
+		}
+		replacement.AddSeat(seat.SeatID, seat.RowID, seat.SeatNumber, seat.SeatType, seat.Price)
+	}
+
+	// Loveseats are sold in pairs
+	if unpaired := target.UnpairedLoveseats(seatIDs); len(unpaired) > 0 {
+		return nil, fmt.Errorf("loveseat %s must be booked together with its pair", unpaired[0])
+	}
+
+	replacement.CalculatePricing(rule, 0.0)
+	if original.Promotion != nil {
+		// A redeemed promo code stays with the exchanged booking while it applies to the new show and seats
+		replacement.TransferPromotion(original.Promotion, promotion, target)
+	}
+	replacement.Pricing.PaidAmount = original.Pricing.PaidAmount - original.RefundedAmount()
+	replacement.PaymentStatus = "completed"
+	replacement.PaymentMethod = original.PaymentMethod
+	replacement.PaymentProvider = original.PaymentProvider
+	replacement.PaymentIntentID = original.PaymentIntentID
+	replacement.Charges = append([]models.PaymentCharge(nil), original.PaymentCharges()...)
+	replacement.TransactionID = original.TransactionID
+	replacement.ExchangedFrom = original.BookingID
+
+	if len(replacement.Seats) == len(original.Seats) && target.ID == original.ShowtimeID {
+		same := true
+		for _, seat := range replacement.Seats {
+			same = same && ownSeats[seat.SeatID]
+		}
+		if same {
+			return nil, fmt.Errorf("the booking already has these seats")
+		}
+	}
+
+	return replacement, nil
+}
+
+// chargeExchangeDifference collects the extra amount owed for a more expensive exchange
+func (h *BookingsHandler) chargeExchangeDifference(ctx context.Context, original, replacement *models.Booking, amount int64) (*payments.Intent, error) {
+	intent, err := h.payments.CreateIntent(ctx, payments.IntentRequest{
+		Amount:         amount,
+		Currency:       paymentCurrency,
+		Reference:      replacement.BookingID,
+		IdempotencyKey: "exchange:" + replacement.BookingID,
+		Metadata: map[string]string{
+			"booking_id":     original.ID.Hex(),
+			"exchanged_from": original.BookingID,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	intent, err = h.payments.Capture(ctx, intent.ID)
+	if err != nil {
+		return nil, err
+	}
+	if intent.CapturedAmount != amount {
+		h.refundCapturedIntent(ctx, intent, "exchange amount mismatch")
+		return nil, payments.ErrAmountMismatch
+	}
+
+	return intent, nil
+}
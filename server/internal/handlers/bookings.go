@@ -2,60 +2,86 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
-	"math/rand"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
 	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/internal/services"
+	"github.com/tejas161/Cinema-Flix/internal/services/notifications"
+	"github.com/tejas161/Cinema-Flix/internal/services/payments"
 	"github.com/tejas161/Cinema-Flix/models"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 	"go.mongodb.org/mongo-driver/v2/mongo/options"
 )
 
+var errBookingNotFound = errors.New("booking not found")
+
+const (
+	// paymentCurrency is the currency all bookings are charged in
+	paymentCurrency = "usd"
+
+	// bookingIDAttempts bounds retries after a booking ID collision
+	bookingIDAttempts = 3
+)
+
 type BookingsHandler struct {
 	bookingsCollection  *mongo.Collection
 	showtimesCollection *mongo.Collection
 	theatersCollection  *mongo.Collection
+	showtimeRepo        *db.ShowtimeRepository
+	theaterRepo         *db.TheaterRepository
+	promotionRepo       *db.PromotionRepository
+	pricingRuleRepo     *db.PricingRuleRepository
+	payments            payments.Provider
+	tickets             *services.TicketService
+	tmdbService         *services.TMDBService
+	mailer              *notifications.BookingMailer
+	pricer              *services.DynamicPricer
 }
 
-func NewBookingsHandler() *BookingsHandler {
+func NewBookingsHandler(paymentProvider payments.Provider, tickets *services.TicketService, mailer *notifications.BookingMailer, pricer *services.DynamicPricer) *BookingsHandler {
 	return &BookingsHandler{
 		bookingsCollection:  config.GetCollection("bookings"),
 		showtimesCollection: config.GetCollection("showtimes"),
 		theatersCollection:  config.GetCollection("theaters"),
+		showtimeRepo:        db.NewShowtimeRepository(),
+		theaterRepo:         db.NewTheaterRepository(),
+		promotionRepo:       db.NewPromotionRepository(),
+		pricingRuleRepo:     db.NewPricingRuleRepository(),
+		payments:            paymentProvider,
+		tickets:             tickets,
+		tmdbService:         services.NewTMDBService(),
+		mailer:              mailer,
+		pricer:              pricer,
 	}
 }
 
 // CreateBooking creates a new booking
 func (h *BookingsHandler) CreateBooking() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get user information from auth headers
-		// Get userId from JSON body (googleId or id field)
-		var userPayload struct {
-			GoogleID string `json:"googleId"`
-			ID       string `json:"id"`
-		}
-		_ = c.BodyParser(&userPayload)
-		log.Printf("User payload: %v", userPayload);
-		userID := userPayload.GoogleID
-		if userID == "" {
-			userID = userPayload.ID
-		}
-
-		if userID == "" {
+		// Get the authenticated user from the session
+		user := middleware.CurrentUser(c)
+		if user == nil {
 			return c.Status(401).JSON(fiber.Map{
 				"success": false,
 				"error":   "User authentication required",
 			})
 		}
-		
+		userID := user.ID.Hex()
+
 		var request struct {
 			ShowtimeID      string                `json:"showtime_id"`
 			SeatIDs         []string             `json:"seat_ids"`
+			PromoCode       string               `json:"promo_code"` // Optional
+			Quantity        int                  `json:"quantity"`   // Picks the best available seats when no seat IDs are given
+			SeatType        string               `json:"seat_type"`  // Optional with quantity: premium, regular
 		}
 
 		if err := c.BodyParser(&request); err != nil {
@@ -66,12 +92,24 @@ func (h *BookingsHandler) CreateBooking() fiber.Handler {
 		}
 
 		// Validate input
-		if len(request.SeatIDs) == 0 {
+		if len(request.SeatIDs) == 0 && request.Quantity == 0 {
 			return c.Status(400).JSON(fiber.Map{
 				"success": false,
 				"error":   "At least one seat must be selected",
 			})
 		}
+		if len(request.SeatIDs) > 0 && request.Quantity > 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Give either seat_ids or quantity, not both",
+			})
+		}
+		if request.Quantity < 0 || request.Quantity > models.MaxBestSeats {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   fmt.Sprintf("quantity must be between 1 and %d", models.MaxBestSeats),
+			})
+		}
 
 		showtimeID, err := bson.ObjectIDFromHex(request.ShowtimeID)
 		if err != nil {
@@ -97,43 +135,63 @@ func (h *BookingsHandler) CreateBooking() fiber.Handler {
 
 		var bookingResult *models.Booking
 
-		// Execute transaction
-		err = mongo.WithSession(ctx, session, func(sc context.Context) error {
+		// The seat hold and the booking insert commit together
+		createBooking := func(sc context.Context) (interface{}, error) {
 			// Get showtime details
 			var showtime models.Showtime
 			err := h.showtimesCollection.FindOne(sc, bson.M{"_id": showtimeID}).Decode(&showtime)
 			if err != nil {
 				if err == mongo.ErrNoDocuments {
-					return fmt.Errorf("showtime not found")
+					return nil, fmt.Errorf("showtime not found")
 				}
-				return fmt.Errorf("failed to fetch showtime: %v", err)
+				return nil, fmt.Errorf("failed to fetch showtime: %v", err)
 			}
 
 			// Check if showtime is available
 			if !showtime.IsAvailable() {
-				return fmt.Errorf("showtime is not available for booking")
+				return nil, fmt.Errorf("showtime is not available for booking")
 			}
 
-			// Validate and block seats
-			totalPrice := 0.0
-			var bookedSeats []models.BookedSeat
+			// Generate booking ID up front so seat holds can reference it
+			bookingID := h.generateBookingID()
 
-			for _, seatID := range request.SeatIDs {
-				seat := showtime.GetSeatByID(seatID)
-				if seat == nil {
-					return fmt.Errorf("seat %s not found", seatID)
+			theater, err := h.theaterRepo.FindTheaterByID(sc, showtime.TheaterID)
+			if err != nil {
+				return nil, err
+			}
+			gapPolicy := models.DefaultSeatGapPolicy
+			if theater != nil {
+				gapPolicy = theater.EffectiveSeatGapPolicy()
+			}
+
+			// Pick the best available seats when only a quantity was requested,
+			// moving to the best block that leaves no single seat behind if needed
+			seatIDs := request.SeatIDs
+			if request.Quantity > 0 {
+				selection, err := showtime.BestAvailableSeats(request.Quantity, request.SeatType)
+				if err != nil {
+					return nil, err
 				}
+				seatIDs = selection.SeatIDs
 
-				if seat.Status != "available" {
-					return fmt.Errorf("seat %s is not available", seatID)
+				var gapErr *models.SeatGapError
+				if errors.As(showtime.CheckSeatGaps(seatIDs, gapPolicy), &gapErr) {
+					seatIDs = gapErr.Suggestions[0]
 				}
+			}
 
-				// Block the seat
-				if !showtime.BookSeat(seatID, userID) {
-					return fmt.Errorf("failed to book seat %s", seatID)
+			// Validate the requested seats against the layout
+			var bookedSeats []models.BookedSeat
+			for _, seatID := range seatIDs {
+				seat := showtime.GetSeatByID(seatID)
+				if seat == nil {
+					return nil, fmt.Errorf("seat %s not found", seatID)
+				}
+
+				if seat.Status != models.SeatAvailable {
+					return nil, fmt.Errorf("seat %s is not available", seatID)
 				}
 
-				// Add to booked seats
 				bookedSeat := models.BookedSeat{
 					SeatID:     seat.SeatID,
 					RowID:      seat.RowID,
@@ -142,29 +200,30 @@ func (h *BookingsHandler) CreateBooking() fiber.Handler {
 					Price:      seat.Price,
 				}
 				bookedSeats = append(bookedSeats, bookedSeat)
-				totalPrice += seat.Price
 			}
 
-			// Update showtime in database
-			updateShowtime := bson.M{
-				"$set": bson.M{
-					"seats":        showtime.Seats,
-					"booked_seats": showtime.BookedSeats,
-					"updated_at":   time.Now(),
-				},
+			// Loveseats are sold in pairs
+			if unpaired := showtime.UnpairedLoveseats(seatIDs); len(unpaired) > 0 {
+				return nil, fmt.Errorf("loveseat %s must be booked together with its pair", unpaired[0])
 			}
 
-			_, err = h.showtimesCollection.UpdateOne(sc, bson.M{"_id": showtimeID}, updateShowtime)
-			if err != nil {
-				return fmt.Errorf("failed to update showtime: %v", err)
+			// Refuse selections that strand a single seat
+			if err := showtime.CheckSeatGaps(seatIDs, gapPolicy); err != nil {
+				return nil, err
 			}
 
-			// Generate booking ID
-			bookingID := h.generateBookingID()
+			// Hold every seat in one conditional update so concurrent bookings cannot both win
+			holdUntil := time.Now().Add(models.BookingHoldDuration)
+			if err := h.showtimeRepo.HoldSeats(sc, showtimeID, seatIDs, userID, bookingID, holdUntil); err != nil {
+				return nil, err
+			}
 
 			// Create Google user info
 			googleUserInfo := models.GoogleUserInfo{
-				GoogleID: userID,
+				GoogleID: user.GoogleID,
+				Email:    user.Email,
+				Name:     user.Name,
+				Picture:  user.Picture,
 			}
 			
 			// Create booking
@@ -178,35 +237,81 @@ func (h *BookingsHandler) CreateBooking() fiber.Handler {
 				showtime.ShowDate,
 				showtime.ShowTime,
 			)
+			booking.ExpiresAt = holdUntil
+			booking.PriceMultiplier = showtime.PriceMultiplier
+			booking.PriceChangeID = showtime.PriceChangeID
 
 			// Add seats to booking
 			for _, seat := range bookedSeats {
 				booking.AddSeat(seat.SeatID, seat.RowID, seat.SeatNumber, seat.SeatType, seat.Price)
 			}
 
-			// Calculate pricing (2% convenience fee, 18% tax, no discount)
-			booking.CalculatePricing(2.0, 18.0, 0.0)
+			// Price with the theater's fee and tax rule, discounted by the promo code if one was given
+			rule, err := h.pricingRuleRepo.RuleForTheater(sc, showtime.TheaterID)
+			if err != nil {
+				return nil, err
+			}
+			booking.CalculatePricing(rule, 0.0)
+			if request.PromoCode != "" {
+				if err := redeemPromotion(sc, h.promotionRepo, request.PromoCode, booking, &showtime, user); err != nil {
+					return nil, err
+				}
+			}
 
 			// Insert booking
 			result, err := h.bookingsCollection.InsertOne(sc, booking)
 			if err != nil {
-				return fmt.Errorf("failed to create booking: %v", err)
+				return nil, fmt.Errorf("failed to create booking: %w", err)
 			}
 
 			booking.ID = result.InsertedID.(bson.ObjectID)
 			bookingResult = booking
 
-			return nil
-		})
+			return nil, nil
+		}
+
+		// Execute transaction, starting over with a new booking ID if the generated one is taken
+		for attempt := 1; ; attempt++ {
+			_, err = session.WithTransaction(ctx, createBooking)
+			if !mongo.IsDuplicateKeyError(err) || attempt == bookingIDAttempts {
+				break
+			}
+			log.Printf("Booking ID collision, retrying (attempt %d)", attempt)
+		}
 
 		if err != nil {
 			log.Printf("Booking transaction failed: %v", err)
+			if errors.Is(err, db.ErrSeatsUnavailable) {
+				return c.Status(409).JSON(fiber.Map{
+					"success": false,
+					"error":   "One or more selected seats were just taken. Please choose different seats.",
+				})
+			}
+			var gapErr *models.SeatGapError
+			if errors.As(err, &gapErr) {
+				return seatGapResponse(c, gapErr)
+			}
+			if errors.Is(err, models.ErrNotEnoughSeats) {
+				return c.Status(409).JSON(fiber.Map{
+					"success": false,
+					"error":   "Not enough seats are available for this show",
+				})
+			}
+			if errors.Is(err, models.ErrPromotionNotApplicable) {
+				return c.Status(422).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			}
 			return c.Status(400).JSON(fiber.Map{
 				"success": false,
 				"error":   err.Error(),
 			})
 		}
 
+		h.notify(notifications.EmailBookingCreated, bookingResult, nil)
+		h.reprice(bookingResult.ShowtimeID)
+
 		// Get additional details for response
 		theater, _ := h.getTheaterDetails(bookingResult.TheaterID)
 		
@@ -254,6 +359,14 @@ func (h *BookingsHandler) GetBookingByID() fiber.Handler {
 			})
 		}
 
+		// Only the owner or staff of the theater may see a booking
+		if !canAccessBooking(middleware.CurrentUser(c), &booking) {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Booking not found",
+			})
+		}
+
 		// Get theater details
 		theater, _ := h.getTheaterDetails(booking.TheaterID)
 
@@ -269,21 +382,85 @@ func (h *BookingsHandler) GetBookingByID() fiber.Handler {
 	}
 }
 
+// GetBookingByReference finds a booking by the reference printed on its ticket
+func (h *BookingsHandler) GetBookingByReference() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ref := models.NormalizeBookingReference(c.Params("bookingRef"))
+		if !models.ValidBookingReference(ref) {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid booking reference, please check the code",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var booking models.Booking
+		err := h.bookingsCollection.FindOne(ctx, bson.M{"booking_id": ref}).Decode(&booking)
+		if err != nil && err != mongo.ErrNoDocuments {
+			log.Printf("Error finding booking by reference: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch booking",
+			})
+		}
+
+		// Only the owner or staff of the theater may see a booking
+		if err != nil || !canAccessBooking(middleware.CurrentUser(c), &booking) {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Booking not found",
+			})
+		}
+
+		theater, _ := h.getTheaterDetails(booking.TheaterID)
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"booking": booking,
+				"theater": theater,
+			},
+		})
+	}
+}
+
+// GetTicketPDF returns a printable PDF ticket for a paid booking
+func (h *BookingsHandler) GetTicketPDF() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		booking, err := findTicketBooking(c, h.bookingsCollection)
+		if err != nil {
+			return ticketErrorResponse(c, err)
+		}
+
+		theater, err := h.getTheaterDetails(booking.TheaterID)
+		if err != nil {
+			log.Printf("Warning: Failed to fetch theater for ticket %s: %v", booking.BookingID, err)
+		}
+
+		pdf, err := h.tickets.TicketPDF(booking, theater, h.movieTitle(booking.MovieID))
+		if err != nil {
+			log.Printf("Error rendering ticket PDF for booking %s: %v", booking.BookingID, err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to render ticket",
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, "application/pdf")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="ticket-%s.pdf"`, booking.BookingID))
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+		return c.Send(pdf)
+	}
+}
+
 // GetUserBookings returns all bookings for a user
 func (h *BookingsHandler) GetUserBookings() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get user information from auth headers
-		var userPayload struct {
-			GoogleID string `json:"googleId"`
-			ID       string `json:"id"`
-		}
-		_ = c.BodyParser(&userPayload)
-		log.Printf("User payload: %v", userPayload);
-		userID := userPayload.GoogleID
-		if userID == "" {
-			userID = userPayload.ID
-		}
-		if userID == "" {
+		// Get the authenticated user from the session
+		user := middleware.CurrentUser(c)
+		if user == nil {
 			return c.Status(401).JSON(fiber.Map{
 				"success": false,
 				"error":   "User authentication required",
@@ -298,8 +475,24 @@ func (h *BookingsHandler) GetUserBookings() fiber.Handler {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		// Resolve whose bookings are requested; only platform admins may list other users
+		owner, err := h.resolveBookingsOwner(user, c.Params("userId"))
+		if err != nil {
+			log.Printf("Error resolving bookings owner: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch bookings",
+			})
+		}
+		if owner == nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "User not found",
+			})
+		}
+
 		// Find user bookings
-		filter := bson.M{"google_user_id": userID}
+		filter := bson.M{"google_user_id": bson.M{"$in": models.OwnerIDs(owner)}}
 		
 		// Count total bookings
 		total, err := h.bookingsCollection.CountDocuments(ctx, filter)
@@ -315,7 +508,7 @@ func (h *BookingsHandler) GetUserBookings() fiber.Handler {
 		findOptions := options.Find()
 		findOptions.SetSkip(int64(skip))
 		findOptions.SetLimit(int64(limit))
-		findOptions.SetSort(bson.D{{"created_at", -1}}) // Latest first
+		findOptions.SetSort(bson.D{{Key: "created_at", Value: -1}}) // Latest first
 		
 		cursor, err := h.bookingsCollection.Find(ctx, filter, findOptions)
 		if err != nil {
@@ -353,7 +546,47 @@ func (h *BookingsHandler) GetUserBookings() fiber.Handler {
 	}
 }
 
+// CreatePaymentIntent starts a payment for the booking total with the payment provider
+func (h *BookingsHandler) CreatePaymentIntent() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bookingID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid booking ID",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		booking, err := h.findPayableBooking(ctx, bookingID, middleware.CurrentUser(c))
+		if err != nil {
+			return h.paymentErrorResponse(c, err)
+		}
+
+		intent, err := h.ensurePaymentIntent(ctx, booking)
+		if err != nil {
+			return h.paymentErrorResponse(c, err)
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"booking_id":        bookingID.Hex(),
+				"payment_intent_id": intent.ID,
+				"provider":          h.payments.Name(),
+				"amount":            payments.FromMinorUnits(intent.Amount),
+				"currency":          intent.Currency,
+				"expires_at":        booking.ExpiresAt,
+			},
+		})
+	}
+}
+
 // ConfirmPayment confirms payment for a booking
+// The amount is taken from the booking and verified with the payment provider;
+// amounts posted by the client are only checked, never trusted.
 func (h *BookingsHandler) ConfirmPayment() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		bookingIDStr := c.Params("id")
@@ -366,9 +599,9 @@ func (h *BookingsHandler) ConfirmPayment() fiber.Handler {
 		}
 
 		var request struct {
-			TransactionID string  `json:"transaction_id"`
-			PaymentMethod string  `json:"payment_method"`
-			PaidAmount    float64 `json:"paid_amount"`
+			PaymentIntentID string  `json:"payment_intent_id"`
+			PaymentMethod   string  `json:"payment_method"`
+			PaidAmount      float64 `json:"paid_amount"`
 		}
 
 		if err := c.BodyParser(&request); err != nil {
@@ -378,27 +611,94 @@ func (h *BookingsHandler) ConfirmPayment() fiber.Handler {
 			})
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		user := middleware.CurrentUser(c)
+		if user == nil {
+			return c.Status(401).JSON(fiber.Map{
+				"success": false,
+				"error":   "User authentication required",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 		defer cancel()
 
-		// Find and update booking
+		booking, err := h.findPayableBooking(ctx, bookingID, user)
+		if err != nil {
+			return h.paymentErrorResponse(c, err)
+		}
+
+		expectedAmount := payments.ToMinorUnits(booking.Pricing.TotalAmount)
+		if request.PaidAmount != 0 && payments.ToMinorUnits(request.PaidAmount) != expectedAmount {
+			return h.paymentErrorResponse(c, payments.ErrAmountMismatch)
+		}
+
+		// Resolve the intent; a client supplied intent must belong to this booking
+		var intent *payments.Intent
+		switch {
+		case request.PaymentIntentID == "":
+			intent, err = h.ensurePaymentIntent(ctx, booking)
+		case booking.PaymentIntentID != "" && request.PaymentIntentID != booking.PaymentIntentID:
+			err = payments.ErrIntentNotFound
+		default:
+			intent, err = h.payments.GetIntent(ctx, request.PaymentIntentID)
+		}
+		if err != nil {
+			return h.paymentErrorResponse(c, err)
+		}
+
+		if intent.Reference != booking.BookingID || intent.Amount != expectedAmount {
+			log.Printf("[PAYMENTS] Intent %s does not match booking %s (amount %d, expected %d)", intent.ID, booking.BookingID, intent.Amount, expectedAmount)
+			return h.paymentErrorResponse(c, payments.ErrAmountMismatch)
+		}
+
+		// Collect the payment
+		intent, err = h.payments.Capture(ctx, intent.ID)
+		if err != nil {
+			return h.paymentErrorResponse(c, err)
+		}
+		if intent.CapturedAmount != expectedAmount {
+			log.Printf("[PAYMENTS] Captured %d for booking %s, expected %d", intent.CapturedAmount, booking.BookingID, expectedAmount)
+			return h.paymentErrorResponse(c, payments.ErrAmountMismatch)
+		}
+
+		paymentMethod := intent.PaymentMethod
+		if paymentMethod == "" {
+			paymentMethod = request.PaymentMethod
+		}
+
+		// Mark the booking paid, but only while its seats are still held and its total is the one captured
 		filter := bson.M{
-			"_id":            bookingID,
-			"payment_status": "pending",
+			"_id":                  bookingID,
+			"payment_status":       "pending",
+			"booking_status":       "confirmed",
+			"expires_at":           bson.M{"$gt": time.Now()},
+			"pricing.total_amount": booking.Pricing.TotalAmount,
 		}
 
 		update := bson.M{
 			"$set": bson.M{
-				"payment_status": "completed",
-				"transaction_id": request.TransactionID,
-				"payment_method": request.PaymentMethod,
-				"pricing.paid_amount": request.PaidAmount,
-				"updated_at":     time.Now(),
+				"payment_status":      "completed",
+				"payment_provider":    h.payments.Name(),
+				"payment_intent_id":   intent.ID,
+				"transaction_id":      intent.ID,
+				"payment_method":      paymentMethod,
+				"pricing.paid_amount": payments.FromMinorUnits(intent.CapturedAmount),
+				"charges":             []models.PaymentCharge{capturedCharge(intent)},
+				"updated_at":          time.Now(),
 			},
 		}
 
-		result, err := h.bookingsCollection.UpdateOne(ctx, filter, update)
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err = h.bookingsCollection.FindOneAndUpdate(ctx, filter, update, opts).Decode(booking)
 		if err != nil {
+			if err == mongo.ErrNoDocuments {
+				// The hold lapsed while the payment was captured; give the money back
+				h.refundCapturedIntent(ctx, intent, "booking expired during payment")
+				return c.Status(409).JSON(fiber.Map{
+					"success": false,
+					"error":   "Booking expired before payment completed. The payment has been refunded.",
+				})
+			}
 			log.Printf("Error updating booking: %v", err)
 			return c.Status(500).JSON(fiber.Map{
 				"success": false,
@@ -406,25 +706,79 @@ func (h *BookingsHandler) ConfirmPayment() fiber.Handler {
 			})
 		}
 
-		if result.MatchedCount == 0 {
-			return c.Status(404).JSON(fiber.Map{
-				"success": false,
-				"error":   "Booking not found or already paid",
-			})
+		// Turn the held seats into booked seats
+		if err := h.showtimeRepo.ConfirmHeldSeats(ctx, booking.ShowtimeID, bookedSeatIDs(booking.Seats), booking.BookingID); err != nil {
+			// The hold reaper confirms seats of paid bookings, so this is recoverable
+			log.Printf("Warning: Failed to confirm held seats for booking %s: %v", booking.BookingID, err)
 		}
 
+		h.notify(notifications.EmailPaymentConfirmed, booking, nil)
+
 		return c.JSON(fiber.Map{
 			"success": true,
 			"data": map[string]interface{}{
 				"booking_id":     bookingID.Hex(),
 				"payment_status": "completed",
+				"paid_amount":    booking.Pricing.PaidAmount,
+				"transaction_id": intent.ID,
 				"message":        "Payment confirmed successfully",
 			},
 		})
 	}
 }
 
-// CancelBooking cancels a booking and releases seats
+// GetCancellationQuote returns the refund a cancellation would produce right now
+func (h *BookingsHandler) GetCancellationQuote() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bookingID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid booking ID",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var booking models.Booking
+		err = h.bookingsCollection.FindOne(ctx, bson.M{"_id": bookingID}).Decode(&booking)
+		if err != nil || !canAccessBooking(middleware.CurrentUser(c), &booking) {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Booking not found",
+			})
+		}
+
+		if booking.BookingStatus != "confirmed" {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   fmt.Sprintf("booking is %s", booking.BookingStatus),
+			})
+		}
+
+		policy, err := h.cancellationPolicy(ctx, booking.TheaterID)
+		if err != nil {
+			log.Printf("Error fetching cancellation policy: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch cancellation policy",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"booking_id": bookingID.Hex(),
+				"policy":     policy,
+				"refund":     policy.Quote(&booking, time.Now()),
+			},
+		})
+	}
+}
+
+// CancelBooking cancels a booking and releases seats.
+// Paid bookings are refunded according to the theater's cancellation policy.
 func (h *BookingsHandler) CancelBooking() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		bookingIDStr := c.Params("id")
@@ -436,6 +790,8 @@ func (h *BookingsHandler) CancelBooking() fiber.Handler {
 			})
 		}
 
+		user := middleware.CurrentUser(c)
+
 		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 		defer cancel()
 
@@ -450,91 +806,663 @@ func (h *BookingsHandler) CancelBooking() fiber.Handler {
 		}
 		defer session.EndSession(ctx)
 
-		err = mongo.WithSession(ctx, session, func(sc context.Context) error {
+		var booking models.Booking
+		var refund *models.Refund
+		_, err = session.WithTransaction(ctx, func(sc context.Context) (interface{}, error) {
+			refund = nil
+
 			// Find booking
-			var booking models.Booking
 			err := h.bookingsCollection.FindOne(sc, bson.M{"_id": bookingID}).Decode(&booking)
 			if err != nil {
 				if err == mongo.ErrNoDocuments {
-					return fmt.Errorf("booking not found")
+					return nil, errBookingNotFound
 				}
-				return fmt.Errorf("failed to fetch booking: %v", err)
+				return nil, fmt.Errorf("failed to fetch booking: %v", err)
+			}
+
+			// Only the owner or staff of the theater may cancel
+			if !canAccessBooking(user, &booking) {
+				return nil, errBookingNotFound
 			}
 
 			// Check if booking can be cancelled
 			if booking.BookingStatus == "cancelled" {
-				return fmt.Errorf("booking is already cancelled")
+				return nil, fmt.Errorf("booking is already cancelled")
+			}
+			if booking.BookingStatus == "expired" {
+				return nil, fmt.Errorf("booking has expired")
+			}
+			if booking.BookingStatus == "exchanged" {
+				return nil, fmt.Errorf("booking was exchanged for booking %s", booking.ExchangedTo)
 			}
 
-			// Get showtime and release seats
-			var showtime models.Showtime
-			err = h.showtimesCollection.FindOne(sc, bson.M{"_id": booking.ShowtimeID}).Decode(&showtime)
+			policy, err := h.cancellationPolicy(sc, booking.TheaterID)
 			if err != nil {
-				log.Printf("Warning: Could not find showtime for seat release: %v", err)
-			} else {
-				// Release seats
-				for _, seat := range booking.Seats {
-					showtime.ReleaseSeat(seat.SeatID)
-				}
+				return nil, fmt.Errorf("failed to fetch cancellation policy: %v", err)
+			}
+			quote := policy.Quote(&booking, time.Now())
 
-				// Update showtime
-				updateShowtime := bson.M{
-					"$set": bson.M{
-						"seats":        showtime.Seats,
-						"booked_seats": showtime.BookedSeats,
-						"updated_at":   time.Now(),
-					},
-				}
+			// Release the seats held or booked by this booking
+			if _, err := h.showtimeRepo.ReleaseBookingSeats(sc, booking.ShowtimeID, bookedSeatIDs(booking.Seats), booking.BookingID); err != nil {
+				return nil, fmt.Errorf("failed to release seats: %v", err)
+			}
 
-				_, err = h.showtimesCollection.UpdateOne(sc, bson.M{"_id": booking.ShowtimeID}, updateShowtime)
-				if err != nil {
-					log.Printf("Warning: Failed to update showtime seats: %v", err)
+			// Give the promo code use back
+			if booking.Promotion != nil {
+				if err := h.promotionRepo.Release(sc, booking.Promotion.PromotionID); err != nil {
+					return nil, err
 				}
 			}
 
-			// Cancel booking
+			// Cancel booking, recording the refund before it is sent to the provider
+			now := time.Now()
 			updateBooking := bson.M{
 				"$set": bson.M{
 					"booking_status": "cancelled",
-					"payment_status": "refunded",
-					"updated_at":     time.Now(),
+					"updated_at":     now,
 				},
 			}
+			if quote.Amount > 0 {
+				refund = &models.Refund{
+					ID:        bson.NewObjectID(),
+					Amount:    quote.Amount,
+					Percent:   quote.Percent,
+					Reason:    quote.Reason,
+					Status:    models.RefundPending,
+					CreatedAt: now,
+					UpdatedAt: now,
+				}
+				updateBooking["$push"] = bson.M{"refunds": refund}
+			}
 
-			_, err = h.bookingsCollection.UpdateOne(sc, bson.M{"_id": bookingID}, updateBooking)
+			result, err := h.bookingsCollection.UpdateOne(sc, bson.M{"_id": bookingID, "booking_status": "confirmed"}, updateBooking)
 			if err != nil {
-				return fmt.Errorf("failed to cancel booking: %v", err)
+				return nil, fmt.Errorf("failed to cancel booking: %v", err)
+			}
+			if result.ModifiedCount == 0 {
+				return nil, fmt.Errorf("booking is already cancelled")
 			}
 
-			return nil
+			return nil, nil
 		})
 
 		if err != nil {
 			log.Printf("Cancellation transaction failed: %v", err)
+			if errors.Is(err, errBookingNotFound) {
+				return c.Status(404).JSON(fiber.Map{
+					"success": false,
+					"error":   "Booking not found",
+				})
+			}
 			return c.Status(400).JSON(fiber.Map{
 				"success": false,
 				"error":   err.Error(),
 			})
 		}
 
+		// Issue the refund outside the transaction; failures stay recorded for follow-up
+		if refund != nil {
+			h.issueRefund(ctx, &booking, refund)
+		}
+
+		h.notify(notifications.EmailBookingCancelled, &booking, refund)
+
 		return c.JSON(fiber.Map{
 			"success": true,
 			"data": map[string]interface{}{
 				"booking_id": bookingID.Hex(),
 				"status":     "cancelled",
+				"refund":     refund,
 				"message":    "Booking cancelled successfully",
 			},
 		})
 	}
 }
 
-// generateBookingID generates a unique booking ID
+// CancelSeats cancels some of the seats of a booking.
+// The pricing is recalculated for the remaining seats and paid bookings get a
+// proportional refund under the theater's cancellation policy.
+func (h *BookingsHandler) CancelSeats() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bookingID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid booking ID",
+			})
+		}
+
+		var request struct {
+			SeatIDs []string `json:"seat_ids"`
+		}
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid request body",
+			})
+		}
+		seatIDs := models.UniqueSeatIDs(request.SeatIDs)
+		if len(seatIDs) == 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "seat_ids is required",
+			})
+		}
+
+		user := middleware.CurrentUser(c)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		session, err := config.MongoClient.StartSession()
+		if err != nil {
+			log.Printf("Error starting session: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to start cancellation process",
+			})
+		}
+		defer session.EndSession(ctx)
+
+		var booking models.Booking
+		var amendment *models.BookingAmendment
+		var refund *models.Refund
+		var staleIntentID string
+		_, err = session.WithTransaction(ctx, func(sc context.Context) (interface{}, error) {
+			refund = nil
+			staleIntentID = ""
+
+			err := h.bookingsCollection.FindOne(sc, bson.M{"_id": bookingID}).Decode(&booking)
+			if err != nil {
+				if err == mongo.ErrNoDocuments {
+					return nil, errBookingNotFound
+				}
+				return nil, fmt.Errorf("failed to fetch booking: %v", err)
+			}
+
+			if !canAccessBooking(user, &booking) {
+				return nil, errBookingNotFound
+			}
+			if booking.BookingStatus != "confirmed" || booking.IsExpired() {
+				return nil, fmt.Errorf("only active bookings can be changed")
+			}
+
+			previousPricing := booking.Pricing
+			removed := booking.RemoveSeats(seatIDs)
+			if len(removed) != len(seatIDs) {
+				return nil, fmt.Errorf("seats %v are not part of this booking", missingSeatIDs(seatIDs, removed))
+			}
+			if len(booking.Seats) == 0 {
+				return nil, fmt.Errorf("cannot remove every seat; cancel the booking instead")
+			}
+
+			// Reprice the promo discount for the remaining seats; a promotion whose minimums
+			// are no longer met stops applying and its use is given back
+			discount := 0.0
+			if booking.Promotion != nil {
+				promotion, err := h.promotionRepo.FindPromotionByID(sc, booking.Promotion.PromotionID)
+				if err != nil {
+					return nil, err
+				}
+				remaining, eligible := booking.RemainingPromotionDiscount(promotion, previousPricing.BaseAmount)
+				if eligible {
+					discount = remaining
+					booking.Promotion.Discount = remaining
+				} else {
+					if err := h.promotionRepo.Release(sc, booking.Promotion.PromotionID); err != nil {
+						return nil, err
+					}
+					booking.Promotion = nil
+				}
+			}
+
+			// Recalculate for the remaining seats with the booking's own rule, keeping what was already paid
+			booking.CalculatePricing(booking.AppliedPricingRule(), discount)
+			booking.Pricing.PaidAmount = previousPricing.PaidAmount
+			remainingPricing := booking.Pricing
+
+			policy, err := h.cancellationPolicy(sc, booking.TheaterID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch cancellation policy: %v", err)
+			}
+			booking.Pricing = previousPricing
+			quote := policy.QuoteSeatRemoval(&booking, remainingPricing, time.Now())
+			booking.Pricing = remainingPricing
+
+			if _, err := h.showtimeRepo.ReleaseBookingSeats(sc, booking.ShowtimeID, seatIDs, booking.BookingID); err != nil {
+				return nil, fmt.Errorf("failed to release seats: %v", err)
+			}
+
+			now := time.Now()
+			amendment = &models.BookingAmendment{
+				ID:              bson.NewObjectID(),
+				Type:            models.AmendmentSeatsRemoved,
+				SeatIDs:         seatIDs,
+				PreviousPricing: previousPricing,
+				Pricing:         remainingPricing,
+				AmendedBy:       user.ID.Hex(),
+				CreatedAt:       now,
+			}
+			push := bson.M{"amendments": amendment}
+			if quote.Amount > 0 {
+				refund = &models.Refund{
+					ID:        bson.NewObjectID(),
+					Amount:    quote.Amount,
+					Percent:   quote.Percent,
+					Reason:    quote.Reason,
+					Status:    models.RefundPending,
+					CreatedAt: now,
+					UpdatedAt: now,
+				}
+				amendment.RefundID = &refund.ID
+				push["refunds"] = refund
+			}
+
+			set := bson.M{
+				"seats":       booking.Seats,
+				"total_seats": booking.TotalSeats,
+				"pricing":     booking.Pricing,
+				"promotion":   booking.Promotion,
+				"updated_at":  now,
+			}
+			if booking.PaymentStatus == "pending" {
+				// The new total needs a new payment intent; the old one is voided once this commits
+				set["payment_intent_id"] = ""
+				staleIntentID = booking.PaymentIntentID
+			}
+
+			// A payment completed meanwhile was for the old total, so the change must be retried
+			filter := bson.M{"_id": bookingID, "booking_status": "confirmed", "payment_status": booking.PaymentStatus}
+			result, err := h.bookingsCollection.UpdateOne(sc, filter, bson.M{
+				"$set":  set,
+				"$push": push,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to update booking: %v", err)
+			}
+			if result.ModifiedCount == 0 {
+				return nil, fmt.Errorf("booking changed while seats were being cancelled, please try again")
+			}
+
+			return nil, nil
+		})
+
+		if err != nil {
+			log.Printf("Seat cancellation transaction failed: %v", err)
+			if errors.Is(err, errBookingNotFound) {
+				return c.Status(404).JSON(fiber.Map{
+					"success": false,
+					"error":   "Booking not found",
+				})
+			}
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+
+		if staleIntentID != "" {
+			h.voidIntent(ctx, staleIntentID, "booking total changed")
+		}
+		if refund != nil {
+			h.issueRefund(ctx, &booking, refund)
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"booking_id":      bookingID.Hex(),
+				"cancelled_seats": seatIDs,
+				"seats":           booking.Seats,
+				"pricing":         booking.Pricing,
+				"amendment":       amendment,
+				"refund":          refund,
+				"message":         "Seats cancelled successfully",
+			},
+		})
+	}
+}
+
+// missingSeatIDs returns the requested seat IDs that were not removed
+func missingSeatIDs(requested []string, removed []models.BookedSeat) []string {
+	found := make(map[string]bool, len(removed))
+	for _, seat := range removed {
+		found[seat.SeatID] = true
+	}
+
+	var missing []string
+	for _, seatID := range requested {
+		if !found[seatID] {
+			missing = append(missing, seatID)
+		}
+	}
+	return missing
+}
+
+// cancellationPolicy returns the cancellation policy of a theater
+func (h *BookingsHandler) cancellationPolicy(ctx context.Context, theaterID bson.ObjectID) (models.CancellationPolicy, error) {
+	var theater models.Theater
+	err := h.theatersCollection.FindOne(ctx, bson.M{"_id": theaterID}).Decode(&theater)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return models.DefaultCancellationPolicy, nil
+		}
+		return models.CancellationPolicy{}, err
+	}
+	return models.EffectiveCancellationPolicy(&theater), nil
+}
+
+// issueRefund sends a recorded refund to the payment provider and stores the result.
+// The refund is split across the booking's charges when one charge cannot cover it.
+func (h *BookingsHandler) issueRefund(ctx context.Context, booking *models.Booking, refund *models.Refund) {
+	refund.Status = models.RefundFailed
+	parts, covered := booking.AllocateRefund(refund.Amount)
+	if !covered {
+		log.Printf("[PAYMENTS] ERROR: Booking %s has no payments left to refund %.2f from", booking.BookingID, refund.Amount)
+		parts = nil
+	}
+
+	returned := 0
+	for i := range parts {
+		result, err := h.payments.Refund(ctx, payments.RefundRequest{
+			IntentID:       parts[i].IntentID,
+			Amount:         payments.ToMinorUnits(parts[i].Amount),
+			Reason:         refund.Reason,
+			IdempotencyKey: "refund:" + refund.ID.Hex() + ":" + parts[i].IntentID,
+		})
+		if err != nil {
+			log.Printf("[PAYMENTS] ERROR: Failed to refund booking %s through intent %s: %v", booking.BookingID, parts[i].IntentID, err)
+			continue
+		}
+		parts[i].ProviderID = result.ID
+		returned++
+	}
+	refund.Parts = parts
+	if covered && returned == len(parts) {
+		refund.Status = models.RefundSucceeded
+		if len(parts) == 1 {
+			refund.ProviderID = parts[0].ProviderID
+		}
+	}
+	refund.UpdatedAt = time.Now()
+
+	set := bson.M{
+		"refunds.$[r].status":      refund.Status,
+		"refunds.$[r].provider_id": refund.ProviderID,
+		"refunds.$[r].parts":       refund.Parts,
+		"refunds.$[r].updated_at":  refund.UpdatedAt,
+		"updated_at":               refund.UpdatedAt,
+	}
+	if refund.Status == models.RefundSucceeded {
+		refunded := refund.Amount
+		for _, previous := range booking.Refunds {
+			if previous.ID != refund.ID && previous.Status != models.RefundFailed {
+				refunded += previous.Amount
+			}
+		}
+
+		set["payment_status"] = "partially_refunded"
+		if payments.ToMinorUnits(refunded) >= payments.ToMinorUnits(booking.Pricing.PaidAmount) {
+			set["payment_status"] = "refunded"
+		}
+	}
+
+	// Count what each charge returned, so later refunds draw on what is left
+	filters := []interface{}{bson.M{"r._id": refund.ID}}
+	inc := bson.M{}
+	charges := booking.PaymentCharges()
+	for _, part := range parts {
+		if part.ProviderID == "" {
+			continue
+		}
+		for i := range charges {
+			if charges[i].IntentID == part.IntentID {
+				charges[i].RefundedAmount += part.Amount
+			}
+		}
+		name := fmt.Sprintf("c%d", len(filters))
+		filters = append(filters, bson.M{name + ".intent_id": part.IntentID})
+		inc["charges.$["+name+"].refunded_amount"] = part.Amount
+	}
+	update := bson.M{"$set": set}
+	if len(booking.Charges) == 0 {
+		// Bookings paid before charges were recorded get theirs now
+		if len(charges) > 0 {
+			set["charges"] = charges
+		}
+		filters = filters[:1]
+	} else if len(inc) > 0 {
+		update["$inc"] = inc
+	}
+
+	opts := options.UpdateOne().SetArrayFilters(filters)
+	if _, err := h.bookingsCollection.UpdateOne(ctx, bson.M{"_id": booking.ID}, update, opts); err != nil {
+		log.Printf("[PAYMENTS] ERROR: Failed to store refund for booking %s: %v", booking.BookingID, err)
+	}
+}
+
+// findPayableBooking loads an unpaid, unexpired booking owned by the user
+func (h *BookingsHandler) findPayableBooking(ctx context.Context, bookingID bson.ObjectID, user *models.User) (*models.Booking, error) {
+	if user == nil {
+		return nil, errBookingNotFound
+	}
+
+	filter := bson.M{
+		"_id":            bookingID,
+		"google_user_id": bson.M{"$in": models.OwnerIDs(user)},
+		"payment_status": "pending",
+		"booking_status": "confirmed",
+		"expires_at":     bson.M{"$gt": time.Now()},
+	}
+
+	var booking models.Booking
+	if err := h.bookingsCollection.FindOne(ctx, filter).Decode(&booking); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, errBookingNotFound
+		}
+		return nil, fmt.Errorf("failed to fetch booking: %v", err)
+	}
+
+	return &booking, nil
+}
+
+// ensurePaymentIntent returns the booking's payment intent, creating it on first use
+func (h *BookingsHandler) ensurePaymentIntent(ctx context.Context, booking *models.Booking) (*payments.Intent, error) {
+	if booking.PaymentIntentID != "" {
+		return h.payments.GetIntent(ctx, booking.PaymentIntentID)
+	}
+
+	intent, err := h.payments.CreateIntent(ctx, payments.IntentRequest{
+		Amount:         payments.ToMinorUnits(booking.Pricing.TotalAmount),
+		Currency:       paymentCurrency,
+		Reference:      booking.BookingID,
+		IdempotencyKey: fmt.Sprintf("booking:%s:%d", booking.ID.Hex(), payments.ToMinorUnits(booking.Pricing.TotalAmount)),
+		Metadata: map[string]string{
+			"booking_id": booking.ID.Hex(),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Remember the intent so retries and webhooks resolve to the same payment
+	filter := bson.M{"_id": booking.ID, "payment_intent_id": bson.M{"$in": []interface{}{"", nil}}}
+	update := bson.M{"$set": bson.M{
+		"payment_provider":  h.payments.Name(),
+		"payment_intent_id": intent.ID,
+		"updated_at":        time.Now(),
+	}}
+	if _, err := h.bookingsCollection.UpdateOne(ctx, filter, update); err != nil {
+		return nil, fmt.Errorf("failed to store payment intent: %v", err)
+	}
+	booking.PaymentProvider = h.payments.Name()
+	booking.PaymentIntentID = intent.ID
+
+	return intent, nil
+}
+
+// refundCapturedIntent returns a captured payment in full
+func (h *BookingsHandler) refundCapturedIntent(ctx context.Context, intent *payments.Intent, reason string) {
+	_, err := h.payments.Refund(ctx, payments.RefundRequest{
+		IntentID:       intent.ID,
+		Amount:         intent.CapturedAmount,
+		Reason:         reason,
+		IdempotencyKey: "void:" + intent.ID,
+	})
+	if err != nil {
+		log.Printf("[PAYMENTS] ERROR: Failed to refund intent %s: %v", intent.ID, err)
+	}
+}
+
+// capturedCharge records a captured intent as a charge of its booking
+func capturedCharge(intent *payments.Intent) models.PaymentCharge {
+	return models.PaymentCharge{
+		IntentID:   intent.ID,
+		Amount:     payments.FromMinorUnits(intent.CapturedAmount),
+		CapturedAt: time.Now(),
+	}
+}
+
+// voidIntent cancels a payment intent a booking no longer uses, refunding it if it was already captured
+func (h *BookingsHandler) voidIntent(ctx context.Context, intentID, reason string) {
+	_, err := h.payments.Cancel(ctx, intentID)
+	if errors.Is(err, payments.ErrIntentCaptured) {
+		var intent *payments.Intent
+		if intent, err = h.payments.GetIntent(ctx, intentID); err == nil {
+			h.refundCapturedIntent(ctx, intent, reason)
+			return
+		}
+	}
+	if err != nil {
+		log.Printf("[PAYMENTS] ERROR: Failed to void intent %s: %v", intentID, err)
+	}
+}
+
+// paymentErrorResponse maps payment errors onto HTTP responses
+func (h *BookingsHandler) paymentErrorResponse(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, errBookingNotFound):
+		return c.Status(404).JSON(fiber.Map{
+			"success": false,
+			"error":   "Booking not found, already paid or expired",
+		})
+	case errors.Is(err, payments.ErrIntentNotFound):
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"error":   "Payment intent does not belong to this booking",
+		})
+	case errors.Is(err, payments.ErrAmountMismatch):
+		return c.Status(400).JSON(fiber.Map{
+			"success": false,
+			"error":   "Payment amount does not match the booking total",
+		})
+	case errors.Is(err, payments.ErrPaymentFailed):
+		return c.Status(402).JSON(fiber.Map{
+			"success": false,
+			"error":   "Payment was declined",
+		})
+	default:
+		log.Printf("[PAYMENTS] ERROR: %v", err)
+		return c.Status(502).JSON(fiber.Map{
+			"success": false,
+			"error":   "Payment provider error",
+		})
+	}
+}
+
+// bookedSeatIDs returns the seat IDs of a booking
+func bookedSeatIDs(seats []models.BookedSeat) []string {
+	ids := make([]string, 0, len(seats))
+	for _, seat := range seats {
+		ids = append(ids, seat.SeatID)
+	}
+	return ids
+}
+
+// canAccessBooking checks if the user owns the booking or is staff for its theater
+func canAccessBooking(user *models.User, booking *models.Booking) bool {
+	if user == nil {
+		return false
+	}
+	return booking.IsOwnedBy(user) || user.CanManageTheater(booking.TheaterID)
+}
+
+// resolveBookingsOwner returns the user whose bookings are requested by the :userId parameter.
+// Users may always list their own bookings ("me", their ID or Google ID); platform admins may
+// list anyone's. Any other request resolves to nil so it is indistinguishable from a missing user.
+func (h *BookingsHandler) resolveBookingsOwner(user *models.User, requestedID string) (*models.User, error) {
+	if requestedID == "" || requestedID == "me" || requestedID == user.ID.Hex() || requestedID == user.GoogleID {
+		return user, nil
+	}
+
+	if !user.HasRole(models.RolePlatformAdmin) {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	userRepo := db.NewUserRepository()
+	if _, err := bson.ObjectIDFromHex(requestedID); err == nil {
+		return userRepo.FindUserByID(ctx, requestedID)
+	}
+	return userRepo.FindUserByGoogleID(ctx, requestedID)
+}
+
+// generateBookingID generates a booking reference such as CF7K3QJ9MZ4.
+// The unique index on booking_id rejects the rare collision, and callers retry.
 func (h *BookingsHandler) generateBookingID() string {
-	// Generate booking ID in format: CF{YYYYMMDD}{6-digit-random}
-	now := time.Now()
-	dateStr := now.Format("20060102")
-	randomNum := rand.Intn(900000) + 100000 // 6-digit random number
-	return fmt.Sprintf("CF%s%d", dateStr, randomNum)
+	return models.NewBookingReference()
+}
+
+// notify sends a booking email in the background.
+// Looking up the movie and rendering the ticket happen off the request path.
+func (h *BookingsHandler) notify(kind string, booking *models.Booking, refund *models.Refund) {
+	snapshot := *booking
+	go func() {
+		details := notifications.BookingDetails{
+			Booking:    &snapshot,
+			MovieTitle: h.movieTitle(snapshot.MovieID),
+		}
+		if theater, err := h.getTheaterDetails(snapshot.TheaterID); err == nil {
+			details.Theater = theater
+		}
+
+		switch kind {
+		case notifications.EmailBookingCreated:
+			h.mailer.BookingCreated(details)
+		case notifications.EmailPaymentConfirmed:
+			pdf, err := h.tickets.TicketPDF(&snapshot, details.Theater, details.MovieTitle)
+			if err != nil {
+				log.Printf("[MAIL] ERROR: Failed to render ticket for booking %s: %v", snapshot.BookingID, err)
+			}
+			h.mailer.PaymentConfirmed(details, pdf)
+		case notifications.EmailBookingCancelled:
+			h.mailer.BookingCancelled(details, refund)
+		}
+	}()
+}
+
+// reprice recomputes a showtime's dynamic prices after its occupancy changed
+func (h *BookingsHandler) reprice(showtimeID bson.ObjectID) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if _, err := h.pricer.Reprice(ctx, showtimeID, models.RepriceBooking); err != nil {
+			log.Printf("[PRICING] ERROR: Failed to reprice showtime %s: %v", showtimeID.Hex(), err)
+		}
+	}()
+}
+
+// movieTitle looks up a movie title on TMDB, returning "" when it is unavailable
+func (h *BookingsHandler) movieTitle(movieID int) string {
+	details, err := h.tmdbService.GetMovieDetails(movieID)
+	if err != nil {
+		log.Printf("Warning: Failed to fetch movie %d: %v", movieID, err)
+		return ""
+	}
+	return details.Title
 }
 
 // getTheaterDetails gets theater details by ID
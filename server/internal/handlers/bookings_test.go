@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/internal/mongotest"
+	"github.com/tejas161/Cinema-Flix/internal/services"
+	"github.com/tejas161/Cinema-Flix/internal/services/notifications"
+	"github.com/tejas161/Cinema-Flix/internal/services/payments"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// bookingAccessFixture holds the users and theater of the booking access tests
+type bookingAccessFixture struct {
+	app        *fiber.App
+	handler    *BookingsHandler
+	provider   *payments.MockProvider
+	theaterID  bson.ObjectID
+	owner      *models.User
+	other      *models.User
+	staff      *models.User
+	otherStaff *models.User
+	admin      *models.User
+}
+
+// newBookingAccessFixture stores the test users and serves the booking routes,
+// authenticating each request as the user named in the X-Test-User header
+func newBookingAccessFixture(t *testing.T) *bookingAccessFixture {
+	t.Helper()
+	mongotest.Setup(t)
+	if err := db.EnsureIndexes(); err != nil {
+		t.Fatalf("creating indexes: %v", err)
+	}
+
+	f := &bookingAccessFixture{theaterID: bson.NewObjectID(), provider: payments.NewMockProvider("test-webhook-secret")}
+	newUser := func(name, role string, theaterIDs ...bson.ObjectID) *models.User {
+		user := models.NewUser("google-"+name, name+"@example.com", name, "")
+		user.ID = bson.NewObjectID()
+		user.Role = role
+		user.ManagedTheaterIDs = theaterIDs
+		if _, err := config.GetCollection(db.UsersCollection).InsertOne(context.Background(), user); err != nil {
+			t.Fatalf("inserting user %s: %v", name, err)
+		}
+		return user
+	}
+	f.owner = newUser("owner", models.RoleCustomer)
+	f.other = newUser("other", models.RoleCustomer)
+	f.staff = newUser("staff", models.RoleTheaterStaff, f.theaterID)
+	f.otherStaff = newUser("other-staff", models.RoleTheaterStaff, bson.NewObjectID())
+	f.admin = newUser("admin", models.RolePlatformAdmin)
+
+	users := map[string]*models.User{}
+	for _, user := range []*models.User{f.owner, f.other, f.staff, f.otherStaff, f.admin} {
+		users[user.Name] = user
+	}
+
+	f.handler = NewBookingsHandler(
+		f.provider,
+		services.NewTicketService(&config.SessionConfig{Secret: []byte(strings.Repeat("s", 32))}),
+		notifications.NewBookingMailer(&config.MailConfig{}),
+		services.NewDynamicPricer(),
+	)
+
+	f.app = fiber.New()
+	f.app.Use(func(c *fiber.Ctx) error {
+		if user, ok := users[c.Get("X-Test-User")]; ok {
+			c.Locals("user", user)
+			c.Locals("userID", user.ID.Hex())
+		}
+		return c.Next()
+	})
+	f.app.Get("/api/bookings/:id", f.handler.GetBookingByID())
+	f.app.Get("/api/users/:userId/bookings", f.handler.GetUserBookings())
+	f.app.Post("/api/bookings/:id/payment-intent", f.handler.CreatePaymentIntent())
+	f.app.Put("/api/bookings/:id/payment", middleware.Idempotency(), f.handler.ConfirmPayment())
+	f.app.Delete("/api/bookings/:id", f.handler.CancelBooking())
+	f.app.Post("/api/payments/webhook", NewPaymentsHandler(f.provider).Webhook())
+	return f
+}
+
+// newBooking stores an unpaid booking of the owner
+func (f *bookingAccessFixture) newBooking(t *testing.T) *models.Booking {
+	t.Helper()
+
+	booking := models.NewBooking(
+		fmt.Sprintf("BK%d", time.Now().UnixNano()),
+		models.GoogleUserInfo{GoogleID: f.owner.GoogleID, Name: f.owner.Name},
+		bson.NewObjectID(),
+		1,
+		f.theaterID,
+		bson.NewObjectID(),
+		time.Now().AddDate(0, 0, 7),
+		time.Now().AddDate(0, 0, 7),
+	)
+	booking.AddSeat("A1", "A", 1, "regular", 20)
+	booking.CalculatePricing(models.DefaultPricingRule, 0)
+
+	result, err := config.GetCollection("bookings").InsertOne(context.Background(), booking)
+	if err != nil {
+		t.Fatalf("inserting booking: %v", err)
+	}
+	booking.ID = result.InsertedID.(bson.ObjectID)
+	return booking
+}
+
+// request sends a request as the given user and returns the status and decoded body
+func (f *bookingAccessFixture) request(t *testing.T, user *models.User, method, path, body string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", user.Name)
+	return f.do(t, req)
+}
+
+// do sends a request and returns the status and decoded body
+func (f *bookingAccessFixture) do(t *testing.T, req *http.Request) (int, map[string]interface{}) {
+	t.Helper()
+
+	resp, err := f.app.Test(req, -1)
+	if err != nil {
+		t.Fatalf("%s %s: %v", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	var decoded map[string]interface{}
+	json.NewDecoder(resp.Body).Decode(&decoded)
+	return resp.StatusCode, decoded
+}
+
+// loadBooking returns the stored booking
+func loadBooking(t *testing.T, bookingID bson.ObjectID) *models.Booking {
+	t.Helper()
+
+	var booking models.Booking
+	if err := config.GetCollection("bookings").FindOne(context.Background(), bson.M{"_id": bookingID}).Decode(&booking); err != nil {
+		t.Fatalf("reloading booking: %v", err)
+	}
+	return &booking
+}
+
+// bookingStatus returns the stored booking status
+func bookingStatus(t *testing.T, bookingID bson.ObjectID) string {
+	t.Helper()
+	return loadBooking(t, bookingID).BookingStatus
+}
+
+func TestGetBookingByIDAccess(t *testing.T) {
+	f := newBookingAccessFixture(t)
+	booking := f.newBooking(t)
+	path := "/api/bookings/" + booking.ID.Hex()
+
+	for _, tc := range []struct {
+		user *models.User
+		want int
+	}{
+		{f.owner, 200},
+		{f.other, 404},
+		{f.otherStaff, 404},
+		{f.staff, 200},
+		{f.admin, 200},
+	} {
+		if status, _ := f.request(t, tc.user, "GET", path, ""); status != tc.want {
+			t.Errorf("%s: status %d, want %d", tc.user.Name, status, tc.want)
+		}
+	}
+}
+
+func TestConfirmPaymentAccess(t *testing.T) {
+	f := newBookingAccessFixture(t)
+	booking := f.newBooking(t)
+	path := "/api/bookings/" + booking.ID.Hex() + "/payment"
+
+	// Only the owner pays for a booking; staff and admins cannot either
+	for _, user := range []*models.User{f.other, f.staff, f.admin} {
+		if status, _ := f.request(t, user, "PUT", path, `{"payment_method":"card"}`); status != 404 {
+			t.Errorf("%s: status %d, want 404", user.Name, status)
+		}
+	}
+
+	if status, body := f.request(t, f.owner, "PUT", path, `{"payment_method":"card"}`); status != 200 {
+		t.Fatalf("owner: status %d (%v), want 200", status, body["error"])
+	}
+}
+
+func TestCancelBookingAccess(t *testing.T) {
+	f := newBookingAccessFixture(t)
+
+	booking := f.newBooking(t)
+	path := "/api/bookings/" + booking.ID.Hex()
+	for _, user := range []*models.User{f.other, f.otherStaff} {
+		if status, _ := f.request(t, user, "DELETE", path, ""); status != 404 {
+			t.Errorf("%s: status %d, want 404", user.Name, status)
+		}
+	}
+	if status := bookingStatus(t, booking.ID); status != "confirmed" {
+		t.Fatalf("booking %s after cancellations by other users", status)
+	}
+
+	for _, user := range []*models.User{f.owner, f.staff, f.admin} {
+		booking := f.newBooking(t)
+		if status, body := f.request(t, user, "DELETE", "/api/bookings/"+booking.ID.Hex(), ""); status != 200 {
+			t.Errorf("%s: status %d (%v), want 200", user.Name, status, body["error"])
+			continue
+		}
+		if status := bookingStatus(t, booking.ID); status != "cancelled" {
+			t.Errorf("%s: booking %s, want cancelled", user.Name, status)
+		}
+	}
+}
+
+func TestGetUserBookingsAccess(t *testing.T) {
+	f := newBookingAccessFixture(t)
+	booking := f.newBooking(t)
+
+	countBookings := func(user *models.User, userID string) (int, int) {
+		status, body := f.request(t, user, "GET", "/api/users/"+userID+"/bookings", "")
+		if status != 200 {
+			return status, 0
+		}
+		data, _ := body["data"].(map[string]interface{})
+		bookings, _ := data["bookings"].([]interface{})
+		for _, b := range bookings {
+			if b.(map[string]interface{})["booking_id"] != booking.BookingID {
+				t.Errorf("listing for %s returned booking %v", userID, b.(map[string]interface{})["booking_id"])
+			}
+		}
+		return status, len(bookings)
+	}
+
+	for _, tc := range []struct {
+		name      string
+		user      *models.User
+		userID    string
+		want      int
+		wantCount int
+	}{
+		{"owner lists own", f.owner, "me", 200, 1},
+		{"other lists own", f.other, "me", 200, 0},
+		{"other by ID", f.other, f.owner.ID.Hex(), 404, 0},
+		{"other by Google ID", f.other, f.owner.GoogleID, 404, 0},
+		{"staff by ID", f.staff, f.owner.ID.Hex(), 404, 0},
+		{"admin by ID", f.admin, f.owner.ID.Hex(), 200, 1},
+		{"admin by Google ID", f.admin, f.owner.GoogleID, 200, 1},
+	} {
+		status, count := countBookings(tc.user, tc.userID)
+		if status != tc.want || count != tc.wantCount {
+			t.Errorf("%s: status %d with %d bookings, want %d with %d", tc.name, status, count, tc.want, tc.wantCount)
+		}
+	}
+}
+
+func TestCreateBookingRejectsInvalidQuantity(t *testing.T) {
+	// Validation happens before any database access
+	handler := &BookingsHandler{}
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals("user", models.NewUser("google-owner", "owner@example.com", "owner", ""))
+		return c.Next()
+	})
+	app.Post("/api/bookings", handler.CreateBooking())
+
+	for _, quantity := range []int{-1, models.MaxBestSeats + 1} {
+		body := fmt.Sprintf(`{"showtime_id":"%s","quantity":%d}`, bson.NewObjectID().Hex(), quantity)
+		req := httptest.NewRequest("POST", "/api/bookings", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req, -1)
+		if err != nil {
+			t.Fatalf("quantity %d: %v", quantity, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != 400 {
+			t.Errorf("quantity %d: status %d, want 400", quantity, resp.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/internal/services"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+const (
+	// defaultShowDuration is used when a showtime has no movie duration
+	defaultShowDuration = 150 * time.Minute
+
+	// calendarFeedHistory is how far back the feed keeps past shows
+	calendarFeedHistory = 30 * 24 * time.Hour
+	calendarFeedLimit   = 200
+)
+
+// CalendarHandler serves bookings as iCalendar events
+type CalendarHandler struct {
+	bookingsCollection  *mongo.Collection
+	showtimesCollection *mongo.Collection
+	theatersCollection  *mongo.Collection
+	feeds               *services.CalendarFeedService
+	tmdbService         *services.TMDBService
+}
+
+func NewCalendarHandler(feeds *services.CalendarFeedService) *CalendarHandler {
+	return &CalendarHandler{
+		bookingsCollection:  config.GetCollection("bookings"),
+		showtimesCollection: config.GetCollection("showtimes"),
+		theatersCollection:  config.GetCollection("theaters"),
+		feeds:               feeds,
+		tmdbService:         services.NewTMDBService(),
+	}
+}
+
+// GetBookingCalendar returns a single booking as an .ics file
+func (h *CalendarHandler) GetBookingCalendar() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		bookingID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid booking ID",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		defer cancel()
+
+		var booking models.Booking
+		err = h.bookingsCollection.FindOne(ctx, bson.M{"_id": bookingID}).Decode(&booking)
+		if err != nil || !canAccessBooking(middleware.CurrentUser(c), &booking) {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Booking not found",
+			})
+		}
+
+		events := h.bookingEvents(ctx, []models.Booking{booking})
+		return sendCalendar(c, "Cinema-Flix "+booking.BookingID, "booking-"+booking.BookingID+".ics", events)
+	}
+}
+
+// GetCalendarFeedURL returns the subscribable feed URL of the current user
+func (h *CalendarHandler) GetCalendarFeedURL() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := middleware.CurrentUser(c)
+		token, err := h.feeds.FeedToken(user)
+		if err != nil {
+			log.Printf("Error creating calendar feed token: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to create calendar feed",
+			})
+		}
+
+		return calendarFeedURLResponse(c, token)
+	}
+}
+
+// ResetCalendarFeed revokes the current user's feed URLs, such as one shared by mistake,
+// and returns a new feed URL
+func (h *CalendarHandler) ResetCalendarFeed() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		token, err := h.feeds.ResetFeedToken(ctx, middleware.CurrentUser(c))
+		if err != nil {
+			log.Printf("Error resetting calendar feed: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to reset calendar feed",
+			})
+		}
+
+		return calendarFeedURLResponse(c, token)
+	}
+}
+
+// calendarFeedURLResponse sends the feed URLs for a feed token
+func calendarFeedURLResponse(c *fiber.Ctx, token string) error {
+	feedURL := c.BaseURL() + "/api/users/me/bookings.ics?token=" + url.QueryEscape(token)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": map[string]interface{}{
+			"feed_url":    feedURL,
+			"webcal_url":  "webcal://" + strings.TrimPrefix(strings.TrimPrefix(feedURL, "https://"), "http://"),
+			"description": "Subscribe to this URL in your calendar app to keep your shows up to date",
+		},
+	})
+}
+
+// GetCalendarFeed returns the user's bookings as a subscribable calendar.
+// Calendar apps cannot send the session cookie, so a signed feed token is accepted instead.
+func (h *CalendarHandler) GetCalendarFeed() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		user := middleware.CurrentUser(c)
+		if token := c.Query("token"); token != "" {
+			var err error
+			user, err = h.feeds.VerifyFeedToken(ctx, token)
+			if err != nil {
+				return c.Status(401).JSON(fiber.Map{
+					"success": false,
+					"error":   "Invalid calendar feed token",
+				})
+			}
+		}
+		if user == nil {
+			return c.Status(401).JSON(fiber.Map{
+				"success": false,
+				"error":   "User authentication required",
+			})
+		}
+
+		// Cancelled and exchanged bookings stay in the feed so subscribed calendars drop them
+		filter := bson.M{
+			"google_user_id": bson.M{"$in": models.OwnerIDs(user)},
+			"booking_status": bson.M{"$in": []string{"confirmed", "cancelled", "exchanged"}},
+			"show_time":      bson.M{"$gte": time.Now().Add(-calendarFeedHistory)},
+		}
+		opts := options.Find().
+			SetSort(bson.D{{Key: "show_time", Value: 1}}).
+			SetLimit(calendarFeedLimit)
+
+		cursor, err := h.bookingsCollection.Find(ctx, filter, opts)
+		if err != nil {
+			log.Printf("Error finding bookings for calendar feed: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch bookings",
+			})
+		}
+		defer cursor.Close(ctx)
+
+		var bookings []models.Booking
+		if err := cursor.All(ctx, &bookings); err != nil {
+			log.Printf("Error decoding bookings for calendar feed: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to decode bookings",
+			})
+		}
+
+		events := h.bookingEvents(ctx, bookings)
+		return sendCalendar(c, "Cinema-Flix bookings", "cinemaflix-bookings.ics", events)
+	}
+}
+
+// bookingEvents builds calendar events from bookings.
+// Times come from the showtime rather than the booking so rescheduled shows move in calendars.
+func (h *CalendarHandler) bookingEvents(ctx context.Context, bookings []models.Booking) []services.CalendarEvent {
+	showtimes := make(map[bson.ObjectID]*models.Showtime)
+	theaters := make(map[bson.ObjectID]*models.Theater)
+	titles := make(map[int]string)
+
+	events := make([]services.CalendarEvent, 0, len(bookings))
+	for i := range bookings {
+		booking := &bookings[i]
+
+		showtime, ok := showtimes[booking.ShowtimeID]
+		if !ok {
+			showtime = &models.Showtime{}
+			if err := h.showtimesCollection.FindOne(ctx, bson.M{"_id": booking.ShowtimeID}).Decode(showtime); err != nil {
+				showtime = nil
+			}
+			showtimes[booking.ShowtimeID] = showtime
+		}
+
+		theater, ok := theaters[booking.TheaterID]
+		if !ok {
+			theater = &models.Theater{}
+			if err := h.theatersCollection.FindOne(ctx, bson.M{"_id": booking.TheaterID}).Decode(theater); err != nil {
+				theater = nil
+			}
+			theaters[booking.TheaterID] = theater
+		}
+
+		title, ok := titles[booking.MovieID]
+		if !ok {
+			if details, err := h.tmdbService.GetMovieDetails(booking.MovieID); err == nil {
+				title = details.Title
+			} else {
+				title = fmt.Sprintf("Movie #%d", booking.MovieID)
+			}
+			titles[booking.MovieID] = title
+		}
+
+		events = append(events, bookingEvent(booking, showtime, theater, title))
+	}
+
+	return events
+}
+
+// bookingEvent describes one booking as a calendar event
+func bookingEvent(booking *models.Booking, showtime *models.Showtime, theater *models.Theater, movieTitle string) services.CalendarEvent {
+	start := booking.ShowTime
+	duration := defaultShowDuration
+	changed := booking.UpdatedAt
+	status := services.EventConfirmed
+
+	if showtime != nil {
+		start = showtime.ShowTime
+		if showtime.Duration > 0 {
+			duration = time.Duration(showtime.Duration) * time.Minute
+		}
+		if showtime.UpdatedAt.After(changed) {
+			changed = showtime.UpdatedAt
+		}
+		if showtime.Status == "cancelled" {
+			status = services.EventCancelled
+		}
+	}
+
+	switch {
+	case booking.BookingStatus != "confirmed":
+		status = services.EventCancelled
+	case booking.PaymentStatus == "pending" && status != services.EventCancelled:
+		status = services.EventTentative
+	}
+
+	seats := make([]string, 0, len(booking.Seats))
+	for _, seat := range booking.Seats {
+		seats = append(seats, seat.SeatID)
+	}
+
+	event := services.CalendarEvent{
+		UID:         booking.ID.Hex() + "@cinemaflix",
+		Sequence:    changed.Unix(),
+		Start:       start,
+		End:         start.Add(duration),
+		Summary:     movieTitle,
+		Description: fmt.Sprintf("Booking %s\nSeats: %s", booking.BookingID, strings.Join(seats, ", ")),
+		Status:      status,
+	}
+
+	if theater != nil {
+		location := []string{theater.Name}
+		for _, part := range []string{theater.Address, theater.City, theater.State, theater.Pincode} {
+			if part != "" {
+				location = append(location, part)
+			}
+		}
+		event.Location = strings.Join(location, ", ")
+
+		if theater.Coordinates.Latitude != 0 || theater.Coordinates.Longitude != 0 {
+			event.HasGeo = true
+			event.Latitude = theater.Coordinates.Latitude
+			event.Longitude = theater.Coordinates.Longitude
+		}
+	}
+
+	return event
+}
+
+// sendCalendar writes an iCalendar response
+func sendCalendar(c *fiber.Ctx, name, filename string, events []services.CalendarEvent) error {
+	c.Set(fiber.HeaderContentType, "text/calendar; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s"`, filename))
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+	return c.Send(services.RenderCalendar(name, events))
+}
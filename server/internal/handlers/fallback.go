@@ -33,6 +33,16 @@ func (h *TheatersHandler) CreateFallbackShowtimes(movieID int) error {
 		return fmt.Errorf("no theaters found")
 	}
 
+	// Resolve each theater's fee and tax rule once
+	rules := make(map[bson.ObjectID]models.PricingRule, len(theaters))
+	for _, theater := range theaters {
+		rule, err := h.pricingRuleRepo.RuleFor(ctx, theater.ID, theater.State)
+		if err != nil {
+			return err
+		}
+		rules[theater.ID] = rule
+	}
+
 	var showtimes []models.Showtime
 	today := time.Now()
 
@@ -68,13 +78,11 @@ func (h *TheatersHandler) CreateFallbackShowtimes(movieID int) error {
 						screen.Type,
 					)
 
-					// Set standard pricing
-					showtime.Pricing = h.getStandardPricing(screen.Type)
-
-					// Initialize seats
-					showtime.Seats = h.initializeSeatsForFallback(screen)
-					showtime.TotalSeats = screen.TotalSeats
-					showtime.BookedSeats = h.getRandomBookedCount(screen.TotalSeats)
+					// Initialize seats priced for the show's time band, and price the show from them
+					showtime.Seats = theater.InitializeSeats(&screen, showTime)
+					showtime.Pricing = rules[theater.ID].ShowPricingForSeats(showtime.Seats)
+					showtime.TotalSeats = len(showtime.Seats)
+					showtime.BookedSeats = 0 // Every seat starts available; the count follows real holds and bookings
 
 					showtimes = append(showtimes, *showtime)
 				}
@@ -100,80 +108,3 @@ func (h *TheatersHandler) CreateFallbackShowtimes(movieID int) error {
 
 	return nil
 }
-
-func (h *TheatersHandler) getStandardPricing(screenType string) models.ShowPricing {
-	premiumBase := 15.99
-	regularBase := 11.99
-	
-	switch screenType {
-	case "IMAX":
-		premiumBase = 20.99
-		regularBase = 16.99
-	case "4DX":
-		premiumBase = 24.99
-		regularBase = 19.99
-	case "3D":
-		premiumBase = 17.99
-		regularBase = 13.99
-	}
-	
-	return models.ShowPricing{
-		Premium: models.PriceRange{
-			BasePrice:      premiumBase,
-			ConvenienceFee: premiumBase * 0.02,
-			Tax:            (premiumBase + premiumBase*0.02) * 0.18,
-			TotalPrice:     premiumBase + (premiumBase * 0.02) + ((premiumBase + premiumBase*0.02) * 0.18),
-		},
-		Regular: models.PriceRange{
-			BasePrice:      regularBase,
-			ConvenienceFee: regularBase * 0.02,
-			Tax:            (regularBase + regularBase*0.02) * 0.18,
-			TotalPrice:     regularBase + (regularBase * 0.02) + ((regularBase + regularBase*0.02) * 0.18),
-		},
-	}
-}
-
-func (h *TheatersHandler) initializeSeatsForFallback(screen models.Screen) []models.Seat {
-	var seats []models.Seat
-
-	for _, row := range screen.SeatLayout.Rows {
-		for seatNum := 1; seatNum <= row.SeatCount; seatNum++ {
-			seatID := fmt.Sprintf("%s%d", row.RowID, seatNum)
-			
-			var price float64
-			switch row.RowType {
-			case "premium":
-				price = row.Price.Afternoon
-			case "regular":
-				price = row.Price.Afternoon
-			default:
-				price = row.Price.Afternoon
-			}
-
-			seat := models.Seat{
-				SeatID:     seatID,
-				RowID:      row.RowID,
-				SeatNumber: seatNum,
-				SeatType:   row.RowType,
-				Status:     "available",
-				Price:      price,
-			}
-			
-			seats = append(seats, seat)
-		}
-	}
-
-	return seats
-}
-
-func (h *TheatersHandler) getRandomBookedCount(totalSeats int) int {
-	// 5-20% random booking for fallback
-	minBooked := int(float64(totalSeats) * 0.05)
-	maxBooked := int(float64(totalSeats) * 0.20)
-	
-	if minBooked == maxBooked {
-		return minBooked
-	}
-	
-	return minBooked + (int(time.Now().UnixNano()) % (maxBooked - minBooked))
-}
\ No newline at end of file
@@ -10,21 +10,36 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/internal/services"
 	"github.com/tejas161/Cinema-Flix/models"
 	"golang.org/x/oauth2"
 )
 
 // GoogleLogin returns a handler function for Google OAuth login
-func GoogleLogin(googleConfig *oauth2.Config) fiber.Handler {
+func GoogleLogin(googleConfig *oauth2.Config, loginStates *services.LoginStateService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Log the login attempt
 
 		log.Printf("[AUTH] Login attempt initiated")
 		log.Printf("  Redirect URI: %s", googleConfig.RedirectURL)
 
-		url := googleConfig.AuthCodeURL("cinema-flix-random-state", oauth2.AccessTypeOffline)
+		// Create a per-login state and PKCE verifier, remembered in a signed cookie
+		loginState, cookieValue, expiresAt, err := loginStates.Begin(c.Query("return_to"))
+		if err != nil {
+			log.Printf("[AUTH] ERROR: Failed to create login state: %v", err)
+			return c.Redirect(getClientURL()+"/login?error=auth_failed", fiber.StatusTemporaryRedirect)
+		}
+		setLoginStateCookie(c, loginStates, cookieValue, expiresAt)
+
+		url := googleConfig.AuthCodeURL(
+			loginState.State,
+			oauth2.AccessTypeOffline,
+			oauth2.S256ChallengeOption(loginState.CodeVerifier),
+		)
 
-		log.Printf("  Redirecting to Google OAuth: %s", url)
+		log.Printf("  Redirecting to Google OAuth (return to %s)", loginState.ReturnTo)
 
 		return c.Redirect(url, fiber.StatusTemporaryRedirect)
 	}
@@ -39,7 +54,7 @@ type UserInfo struct {
 }
 
 // GoogleCallback returns a handler function for Google OAuth callback
-func GoogleCallback(googleConfig *oauth2.Config) fiber.Handler {
+func GoogleCallback(googleConfig *oauth2.Config, loginStates *services.LoginStateService, sessions *services.SessionService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		clientIP := c.IP()
 		code := c.Query("code")
@@ -48,7 +63,17 @@ func GoogleCallback(googleConfig *oauth2.Config) fiber.Handler {
 		log.Printf("[AUTH] OAuth callback received")
 		log.Printf("  Client IP: %s", clientIP)
 		log.Printf("  Auth Code: %s...", code[:min(len(code), 20)]) // Only show first 20 chars for security
-		log.Printf("  State: %s", state)
+
+		// The login state cookie is single use
+		cookieValue := c.Cookies(services.LoginStateCookieName)
+		clearLoginStateCookie(c, loginStates)
+
+		loginState, err := loginStates.Complete(cookieValue, state)
+		if err != nil {
+			log.Printf("[AUTH] ERROR: OAuth state verification failed: %v", err)
+			log.Printf("  Client IP: %s", clientIP)
+			return c.Redirect(getClientURL()+"/login?error=invalid_state", fiber.StatusTemporaryRedirect)
+		}
 
 		if code == "" {
 			log.Printf("[AUTH] ERROR: No authorization code received")
@@ -57,7 +82,7 @@ func GoogleCallback(googleConfig *oauth2.Config) fiber.Handler {
 
 		// Exchange authorization code for token
 		log.Printf("[AUTH] Exchanging authorization code for token...")
-		token, err := googleConfig.Exchange(context.Background(), code)
+		token, err := googleConfig.Exchange(context.Background(), code, oauth2.VerifierOption(loginState.CodeVerifier))
 		if err != nil {
 			log.Printf("[AUTH] ERROR: Token exchange failed: %v", err)
 			log.Printf("  Client IP: %s", clientIP)
@@ -101,30 +126,32 @@ func GoogleCallback(googleConfig *oauth2.Config) fiber.Handler {
 
 		log.Printf("[AUTH] User successfully saved to database")
 
-		// Create response with database user info
-		responseData := fiber.Map{
-			"id":         savedUser.ID.Hex(),
-			"google_id":  savedUser.GoogleID,
-			"email":      savedUser.Email,
-			"name":       savedUser.Name,
-			"picture":    savedUser.Picture,
-			"created_at": savedUser.CreatedAt,
-			"updated_at": savedUser.UpdatedAt,
+		// Promote bootstrap administrators listed in the environment
+		if config.IsPlatformAdminEmail(savedUser.Email) && savedUser.Role != models.RolePlatformAdmin {
+			promotedUser, err := userRepo.UpdateUserRole(ctx, savedUser.ID, models.RolePlatformAdmin, savedUser.ManagedTheaterIDs)
+			if err != nil {
+				log.Printf("[AUTH] ERROR: Failed to promote platform admin: %v", err)
+			} else if promotedUser != nil {
+				log.Printf("[AUTH] Promoted %s to platform admin", promotedUser.Email)
+				savedUser = promotedUser
+			}
 		}
 
-		// Encode user data as URL parameters
-		userDataJSON, err := json.Marshal(responseData)
+		// Create a signed server-side session for the user
+		sessionToken, session, err := sessions.CreateSession(ctx, savedUser, c.Get("User-Agent"), clientIP)
 		if err != nil {
-			log.Printf("[AUTH] ERROR: Failed to marshal user data: %v", err)
-			return c.Redirect(getClientURL()+"/login?error=marshal_failed", fiber.StatusTemporaryRedirect)
+			log.Printf("[AUTH] ERROR: Failed to create session: %v", err)
+			return c.Redirect(getClientURL()+"/login?error=session_failed", fiber.StatusTemporaryRedirect)
 		}
-		encodedUserData := url.QueryEscape(string(userDataJSON))
 
-		log.Printf("[AUTH] Redirecting user to frontend...")
+		middleware.SetSessionCookie(c, sessions, sessionToken, session.ExpiresAt)
+
+		log.Printf("[AUTH] Session created, redirecting user to frontend...")
 		log.Printf("  Redirect URL: %s/auth/callback", getClientURL())
 
-		// Redirect to frontend with user data
-		return c.Redirect(getClientURL()+"/auth/callback?user="+encodedUserData, fiber.StatusTemporaryRedirect)
+		// The frontend loads the profile through the session cookie and
+		// then returns the user to where the login started
+		return c.Redirect(getClientURL()+"/auth/callback?return_to="+url.QueryEscape(loginState.ReturnTo), fiber.StatusTemporaryRedirect)
 	}
 }
 
@@ -140,6 +167,22 @@ func getCurrentTimestamp() string {
 	return time.Now().Format("2006-01-02 15:04:05")
 }
 
+func setLoginStateCookie(c *fiber.Ctx, loginStates *services.LoginStateService, value string, expiresAt time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     services.LoginStateCookieName,
+		Value:    value,
+		Path:     "/auth/google",
+		Expires:  expiresAt,
+		HTTPOnly: true,
+		Secure:   loginStates.CookieSecure(),
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+func clearLoginStateCookie(c *fiber.Ctx, loginStates *services.LoginStateService) {
+	setLoginStateCookie(c, loginStates, "", time.Unix(0, 0))
+}
+
 func getClientURL() string {
 	clientURL := os.Getenv("CLIENT_URL")
 	if clientURL == "" {
@@ -195,16 +238,27 @@ func GetUser() fiber.Handler {
 	}
 }
 
-// Logout handles user logout
-func Logout() fiber.Handler {
+// Logout handles user logout by revoking the current session
+func Logout(sessions *services.SessionService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 
 		log.Printf("[AUTH] User logout initiated")
 
-		// In a real application, you might want to:
-		// - Invalidate tokens in database
-		// - Clear server-side sessions
-		// - Log to audit trail
+		token := middleware.SessionToken(c, sessions)
+		if token != "" {
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			if err := sessions.RevokeSession(ctx, token); err != nil && err != services.ErrSessionInvalid {
+				log.Printf("[AUTH] ERROR: Failed to revoke session: %v", err)
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"success": false,
+					"error":   "Failed to log out",
+				})
+			}
+		}
+
+		middleware.ClearSessionCookie(c, sessions)
 
 		log.Printf("[AUTH] User logout completed successfully")
 
@@ -242,13 +296,15 @@ func GetProfile() fiber.Handler {
 		return c.JSON(fiber.Map{
 			"success": true,
 			"user": fiber.Map{
-				"id":         userModel.ID.Hex(),
-				"google_id":  userModel.GoogleID,
-				"email":      userModel.Email,
-				"name":       userModel.Name,
-				"picture":    userModel.Picture,
-				"created_at": userModel.CreatedAt,
-				"updated_at": userModel.UpdatedAt,
+				"id":                  userModel.ID.Hex(),
+				"google_id":           userModel.GoogleID,
+				"email":               userModel.Email,
+				"name":                userModel.Name,
+				"picture":             userModel.Picture,
+				"role":                userModel.EffectiveRole(),
+				"managed_theater_ids": userModel.ManagedTheaterIDs,
+				"created_at":          userModel.CreatedAt,
+				"updated_at":          userModel.UpdatedAt,
 			},
 		})
 	}
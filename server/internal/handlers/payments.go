@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/services/payments"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type PaymentsHandler struct {
+	bookingsCollection *mongo.Collection
+	bookingRepo        *db.BookingRepository
+	showtimeRepo       *db.ShowtimeRepository
+	eventRepo          *db.PaymentEventRepository
+	promotionRepo      *db.PromotionRepository
+	payments           payments.Provider
+}
+
+func NewPaymentsHandler(paymentProvider payments.Provider) *PaymentsHandler {
+	return &PaymentsHandler{
+		bookingsCollection: config.GetCollection("bookings"),
+		bookingRepo:        db.NewBookingRepository(),
+		showtimeRepo:       db.NewShowtimeRepository(),
+		eventRepo:          db.NewPaymentEventRepository(),
+		promotionRepo:      db.NewPromotionRepository(),
+		payments:           paymentProvider,
+	}
+}
+
+// Webhook applies signed payment provider events to bookings.
+// Each event is applied at most once; deliveries that fail are released so the
+// provider's retry is processed again.
+func (h *PaymentsHandler) Webhook() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		event, err := h.payments.VerifyWebhook(c.Body(), c.Get(payments.WebhookSignatureHeader))
+		if err != nil {
+			log.Printf("[PAYMENTS] Rejected webhook: %v", err)
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid webhook signature or payload",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+		defer cancel()
+
+		provider := h.payments.Name()
+		existing, err := h.eventRepo.Reserve(ctx, &models.PaymentEvent{
+			Provider:   provider,
+			EventID:    event.ID,
+			Type:       event.Type,
+			IntentID:   event.IntentID,
+			Amount:     event.Amount,
+			Status:     "processing",
+			ReceivedAt: time.Now(),
+		})
+		if err != nil {
+			log.Printf("[PAYMENTS] ERROR: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to record webhook event",
+			})
+		}
+		if existing != nil {
+			if existing.Status == "processing" {
+				// Another delivery of this event is in flight; ask the provider to retry later
+				return c.Status(409).JSON(fiber.Map{
+					"success": false,
+					"error":   "Event is already being processed",
+				})
+			}
+			return c.JSON(fiber.Map{
+				"success": true,
+				"data": map[string]interface{}{
+					"event_id":  event.ID,
+					"duplicate": true,
+					"outcome":   existing.Outcome,
+				},
+			})
+		}
+
+		bookingID, outcome, err := h.applyEvent(ctx, event)
+		if err != nil {
+			log.Printf("[PAYMENTS] ERROR: event %s (%s): %v", event.ID, event.Type, err)
+			if releaseErr := h.eventRepo.Release(ctx, provider, event.ID); releaseErr != nil {
+				log.Printf("[PAYMENTS] ERROR: %v", releaseErr)
+			}
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to process webhook event",
+			})
+		}
+
+		status := "processed"
+		if bookingID == "" || outcome == "ignored" {
+			status = "ignored"
+		}
+		if err := h.eventRepo.Complete(ctx, provider, event.ID, status, bookingID, outcome); err != nil {
+			log.Printf("[PAYMENTS] ERROR: %v", err)
+		}
+
+		log.Printf("[PAYMENTS] Webhook %s (%s) for intent %s: %s", event.ID, event.Type, event.IntentID, outcome)
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"event_id": event.ID,
+				"outcome":  outcome,
+			},
+		})
+	}
+}
+
+// applyEvent maps a provider event onto the booking paid with its intent
+func (h *PaymentsHandler) applyEvent(ctx context.Context, event *payments.WebhookEvent) (string, string, error) {
+	booking, err := h.bookingRepo.FindBookingByPaymentIntent(ctx, h.payments.Name(), event.IntentID)
+	if err != nil {
+		return "", "", err
+	}
+	if booking == nil {
+		return "", "ignored", nil // Not one of our intents
+	}
+
+	var outcome string
+	switch event.Type {
+	case payments.EventPaymentSucceeded:
+		outcome, err = h.paymentSucceeded(ctx, booking, event)
+	case payments.EventPaymentFailed:
+		outcome, err = h.paymentFailed(ctx, booking)
+	case payments.EventPaymentRefunded:
+		outcome, err = h.paymentRefunded(ctx, booking, event)
+	case payments.EventPaymentDisputed:
+		outcome, err = h.paymentDisputed(ctx, booking)
+	default:
+		outcome = "ignored"
+	}
+
+	return booking.BookingID, outcome, err
+}
+
+// paymentSucceeded completes an unpaid booking, refunding payments that arrive too late or for the wrong amount.
+// The event only says which intent to look at; the intent fetched from the provider decides
+// whether the booking was paid and for how much.
+func (h *PaymentsHandler) paymentSucceeded(ctx context.Context, booking *models.Booking, event *payments.WebhookEvent) (string, error) {
+	if booking.PaymentStatus == "completed" {
+		return "ignored", nil // Already confirmed by the browser
+	}
+
+	intent, err := h.payments.GetIntent(ctx, event.IntentID)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch intent %s: %v", event.IntentID, err)
+	}
+	if intent.ID != booking.PaymentIntentID || intent.Reference != booking.BookingID {
+		log.Printf("[PAYMENTS] Intent %s does not belong to booking %s", intent.ID, booking.BookingID)
+		return "ignored", nil
+	}
+	if intent.Status != payments.IntentSucceeded {
+		log.Printf("[PAYMENTS] Intent %s for booking %s is %s, not succeeded", intent.ID, booking.BookingID, intent.Status)
+		return "ignored", nil
+	}
+
+	// Refunds and the paid amount use what the provider actually captured
+	captured := *event
+	captured.Amount = intent.CapturedAmount
+
+	expectedAmount := payments.ToMinorUnits(booking.Pricing.TotalAmount)
+	if intent.Amount != expectedAmount || intent.CapturedAmount != expectedAmount || !strings.EqualFold(intent.Currency, paymentCurrency) {
+		log.Printf("[PAYMENTS] Intent %s captured %d %s for booking %s, expected %d %s",
+			intent.ID, intent.CapturedAmount, intent.Currency, booking.BookingID, expectedAmount, paymentCurrency)
+		return h.refundEvent(ctx, &captured, "amount_mismatch")
+	}
+	event = &captured
+
+	// The intent must still be the booking's; seat changes replace it before voiding it
+	filter := bson.M{
+		"_id":               booking.ID,
+		"payment_status":    "pending",
+		"booking_status":    "confirmed",
+		"payment_intent_id": intent.ID,
+		"expires_at":        bson.M{"$gt": time.Now()},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"payment_status":      "completed",
+			"transaction_id":      event.IntentID,
+			"pricing.paid_amount": payments.FromMinorUnits(event.Amount),
+			"charges":             []models.PaymentCharge{capturedCharge(intent)},
+			"updated_at":          time.Now(),
+		},
+	}
+
+	result, err := h.bookingsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", fmt.Errorf("failed to complete booking: %v", err)
+	}
+	if result.ModifiedCount == 0 {
+		// The hold lapsed or the booking was cancelled; its seats may already be resold
+		return h.refundEvent(ctx, event, "refunded_late_payment")
+	}
+
+	if err := h.showtimeRepo.ConfirmHeldSeats(ctx, booking.ShowtimeID, bookedSeatIDs(booking.Seats), booking.BookingID); err != nil {
+		// The hold reaper confirms seats of paid bookings, so this is recoverable
+		log.Printf("Warning: Failed to confirm held seats for booking %s: %v", booking.BookingID, err)
+	}
+
+	return "completed", nil
+}
+
+// paymentFailed cancels an unpaid booking and releases its seats
+func (h *PaymentsHandler) paymentFailed(ctx context.Context, booking *models.Booking) (string, error) {
+	filter := bson.M{
+		"_id":            booking.ID,
+		"payment_status": "pending",
+		"booking_status": "confirmed",
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"payment_status": "failed",
+			"booking_status": "cancelled",
+			"updated_at":     time.Now(),
+		},
+	}
+
+	return h.updateAndRelease(ctx, booking, filter, update, "failed")
+}
+
+// paymentRefunded records a refund; a full refund cancels the booking and releases its seats
+func (h *PaymentsHandler) paymentRefunded(ctx context.Context, booking *models.Booking, event *payments.WebhookEvent) (string, error) {
+	filter := bson.M{
+		"_id":            booking.ID,
+		"payment_status": bson.M{"$in": []string{"completed", "partially_refunded", "disputed"}},
+	}
+
+	if event.Amount > 0 && event.Amount < payments.ToMinorUnits(booking.Pricing.PaidAmount) {
+		update := bson.M{
+			"$set": bson.M{
+				"payment_status": "partially_refunded",
+				"updated_at":     time.Now(),
+			},
+		}
+		result, err := h.bookingsCollection.UpdateOne(ctx, filter, update)
+		if err != nil {
+			return "", fmt.Errorf("failed to update booking: %v", err)
+		}
+		if result.ModifiedCount == 0 {
+			return "ignored", nil
+		}
+		return "partially_refunded", nil
+	}
+
+	filter["booking_status"] = "confirmed"
+	update := bson.M{
+		"$set": bson.M{
+			"payment_status": "refunded",
+			"booking_status": "cancelled",
+			"updated_at":     time.Now(),
+		},
+	}
+
+	return h.updateAndRelease(ctx, booking, filter, update, "refunded")
+}
+
+// paymentDisputed flags a paid booking whose payment was charged back
+func (h *PaymentsHandler) paymentDisputed(ctx context.Context, booking *models.Booking) (string, error) {
+	filter := bson.M{
+		"_id":            booking.ID,
+		"payment_status": bson.M{"$in": []string{"completed", "partially_refunded"}},
+	}
+	update := bson.M{
+		"$set": bson.M{
+			"payment_status": "disputed",
+			"updated_at":     time.Now(),
+		},
+	}
+
+	result, err := h.bookingsCollection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return "", fmt.Errorf("failed to update booking: %v", err)
+	}
+	if result.ModifiedCount == 0 {
+		return "ignored", nil
+	}
+	return "disputed", nil
+}
+
+// updateAndRelease applies a conditional booking update and releases the booking's seats in one transaction
+func (h *PaymentsHandler) updateAndRelease(ctx context.Context, booking *models.Booking, filter, update bson.M, outcome string) (string, error) {
+	session, err := config.MongoClient.StartSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to start session: %v", err)
+	}
+	defer session.EndSession(ctx)
+
+	errNotApplicable := errors.New("booking is not in a state this event applies to")
+
+	_, err = session.WithTransaction(ctx, func(sc context.Context) (interface{}, error) {
+		result, err := h.bookingsCollection.UpdateOne(sc, filter, update)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update booking: %v", err)
+		}
+		if result.ModifiedCount == 0 {
+			return nil, errNotApplicable
+		}
+
+		if _, err := h.showtimeRepo.ReleaseBookingSeats(sc, booking.ShowtimeID, bookedSeatIDs(booking.Seats), booking.BookingID); err != nil {
+			return nil, fmt.Errorf("failed to release seats: %v", err)
+		}
+
+		if booking.Promotion != nil {
+			if err := h.promotionRepo.Release(sc, booking.Promotion.PromotionID); err != nil {
+				return nil, err
+			}
+		}
+
+		return nil, nil
+	})
+	if errors.Is(err, errNotApplicable) {
+		return "ignored", nil
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return outcome, nil
+}
+
+// refundEvent returns the money captured by an event that cannot be applied to its booking
+func (h *PaymentsHandler) refundEvent(ctx context.Context, event *payments.WebhookEvent, outcome string) (string, error) {
+	_, err := h.payments.Refund(ctx, payments.RefundRequest{
+		IntentID:       event.IntentID,
+		Amount:         event.Amount,
+		Reason:         outcome,
+		IdempotencyKey: "void:" + event.IntentID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to refund intent %s: %v", event.IntentID, err)
+	}
+	return outcome, nil
+}
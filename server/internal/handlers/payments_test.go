@@ -0,0 +1,278 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/internal/services/payments"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// createIntent starts the payment of a booking as its owner and returns the intent ID
+func (f *bookingAccessFixture) createIntent(t *testing.T, booking *models.Booking) string {
+	t.Helper()
+
+	status, body := f.request(t, f.owner, "POST", "/api/bookings/"+booking.ID.Hex()+"/payment-intent", "")
+	if status != 200 {
+		t.Fatalf("creating payment intent: status %d (%v)", status, body["error"])
+	}
+	data, _ := body["data"].(map[string]interface{})
+	amount, _ := data["amount"].(float64)
+	if payments.ToMinorUnits(amount) != payments.ToMinorUnits(booking.Pricing.TotalAmount) {
+		t.Fatalf("intent amount %v, want the booking total %v", data["amount"], booking.Pricing.TotalAmount)
+	}
+	intentID, _ := data["payment_intent_id"].(string)
+	return intentID
+}
+
+// confirmPayment confirms the payment of a booking as its owner, with an optional Idempotency-Key
+func (f *bookingAccessFixture) confirmPayment(t *testing.T, booking *models.Booking, body, key string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest("PUT", "/api/bookings/"+booking.ID.Hex()+"/payment", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", f.owner.Name)
+	if key != "" {
+		req.Header.Set(middleware.IdempotencyKeyHeader, key)
+	}
+	return f.do(t, req)
+}
+
+// sendWebhook posts a provider event with the given signature
+func (f *bookingAccessFixture) sendWebhook(t *testing.T, payload []byte, signature string) (int, map[string]interface{}) {
+	t.Helper()
+
+	req := httptest.NewRequest("POST", "/api/payments/webhook", strings.NewReader(string(payload)))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(payments.WebhookSignatureHeader, signature)
+	return f.do(t, req)
+}
+
+func TestConfirmPaymentCapturesIntent(t *testing.T) {
+	f := newBookingAccessFixture(t)
+	booking := f.newBooking(t)
+	intentID := f.createIntent(t, booking)
+
+	// Amounts posted by the client are checked, never trusted
+	if status, _ := f.confirmPayment(t, booking, `{"payment_intent_id":"`+intentID+`","paid_amount":0.01}`, ""); status != 400 {
+		t.Fatalf("confirming a wrong amount: status %d, want 400", status)
+	}
+	if intent, _ := f.provider.GetIntent(context.Background(), intentID); intent.Status != payments.IntentRequiresCapture {
+		t.Fatalf("intent %s after a rejected confirmation, want it uncaptured", intent.Status)
+	}
+
+	// A retry with the same key replays the confirmation instead of failing as already paid
+	body := `{"payment_intent_id":"` + intentID + `","payment_method":"card"}`
+	for attempt := 1; attempt <= 2; attempt++ {
+		status, response := f.confirmPayment(t, booking, body, "confirm-"+booking.BookingID)
+		if status != 200 {
+			t.Fatalf("attempt %d: status %d (%v), want 200", attempt, status, response["error"])
+		}
+		if data, _ := response["data"].(map[string]interface{}); data["transaction_id"] != intentID {
+			t.Fatalf("attempt %d: transaction %v, want %s", attempt, data["transaction_id"], intentID)
+		}
+	}
+	if status, _ := f.confirmPayment(t, booking, body, ""); status != 404 {
+		t.Fatalf("confirming a paid booking without a key: status %d, want 404", status)
+	}
+
+	total := payments.ToMinorUnits(booking.Pricing.TotalAmount)
+	intent, _ := f.provider.GetIntent(context.Background(), intentID)
+	if intent.Status != payments.IntentSucceeded || intent.CapturedAmount != total {
+		t.Fatalf("intent %s with %d captured, want the booking total captured", intent.Status, intent.CapturedAmount)
+	}
+
+	stored := loadBooking(t, booking.ID)
+	if stored.PaymentStatus != "completed" || payments.ToMinorUnits(stored.Pricing.PaidAmount) != total {
+		t.Fatalf("booking %s with %v paid, want completed with %v", stored.PaymentStatus, stored.Pricing.PaidAmount, booking.Pricing.TotalAmount)
+	}
+	if len(stored.Charges) != 1 || stored.Charges[0].IntentID != intentID || payments.ToMinorUnits(stored.Charges[0].Amount) != total {
+		t.Fatalf("charges %+v, want the captured intent", stored.Charges)
+	}
+}
+
+func TestConfirmPaymentRejectsForeignIntent(t *testing.T) {
+	f := newBookingAccessFixture(t)
+	booking := f.newBooking(t)
+
+	// An intent for the right amount but another booking cannot pay for this one
+	foreign, err := f.provider.CreateIntent(context.Background(), payments.IntentRequest{
+		Amount:    payments.ToMinorUnits(booking.Pricing.TotalAmount),
+		Currency:  paymentCurrency,
+		Reference: "BK-OTHER",
+	})
+	if err != nil {
+		t.Fatalf("creating intent: %v", err)
+	}
+
+	if status, _ := f.confirmPayment(t, booking, `{"payment_intent_id":"`+foreign.ID+`"}`, ""); status != 400 {
+		t.Fatalf("confirming with a foreign intent: status %d, want 400", status)
+	}
+	if stored := loadBooking(t, booking.ID); stored.PaymentStatus != "pending" {
+		t.Fatalf("booking %s, want pending", stored.PaymentStatus)
+	}
+	if intent, _ := f.provider.GetIntent(context.Background(), foreign.ID); intent.Status != payments.IntentRequiresCapture {
+		t.Fatalf("foreign intent %s, want it uncaptured", intent.Status)
+	}
+}
+
+func TestPaymentWebhook(t *testing.T) {
+	f := newBookingAccessFixture(t)
+	booking := f.newBooking(t)
+	intentID := f.createIntent(t, booking)
+
+	// The customer pays at the provider, which reports it with a webhook
+	intent, err := f.provider.Capture(context.Background(), intentID)
+	if err != nil {
+		t.Fatalf("capturing intent: %v", err)
+	}
+	payload, _ := json.Marshal(payments.WebhookEvent{
+		ID:        "evt_" + bson.NewObjectID().Hex(),
+		Type:      payments.EventPaymentSucceeded,
+		IntentID:  intentID,
+		Amount:    intent.CapturedAmount,
+		CreatedAt: time.Now(),
+	})
+
+	forged := payments.NewMockProvider("forged-secret").SignWebhook(payload, time.Now())
+	for _, signature := range []string{"", forged, f.provider.SignWebhook(payload, time.Now().Add(-time.Hour))} {
+		if status, _ := f.sendWebhook(t, payload, signature); status != 400 {
+			t.Errorf("signature %q: status %d, want 400", signature, status)
+		}
+	}
+	if stored := loadBooking(t, booking.ID); stored.PaymentStatus != "pending" {
+		t.Fatalf("booking %s after rejected webhooks, want pending", stored.PaymentStatus)
+	}
+
+	status, body := f.sendWebhook(t, payload, f.provider.SignWebhook(payload, time.Now()))
+	if data, _ := body["data"].(map[string]interface{}); status != 200 || data["outcome"] != "completed" {
+		t.Fatalf("webhook: status %d (%v), want 200 completed", status, body)
+	}
+	stored := loadBooking(t, booking.ID)
+	if stored.PaymentStatus != "completed" || len(stored.Charges) != 1 || stored.Charges[0].IntentID != intentID {
+		t.Fatalf("booking %s with charges %+v, want completed by the intent", stored.PaymentStatus, stored.Charges)
+	}
+
+	// Redelivery of the same event is acknowledged without being applied again
+	status, body = f.sendWebhook(t, payload, f.provider.SignWebhook(payload, time.Now()))
+	data, _ := body["data"].(map[string]interface{})
+	if status != 200 || data["duplicate"] != true || data["outcome"] != "completed" {
+		t.Fatalf("redelivery: status %d (%v), want 200 duplicate", status, body)
+	}
+
+	// The browser confirming afterwards finds the booking already paid
+	if status, _ := f.confirmPayment(t, booking, `{"payment_intent_id":"`+intentID+`"}`, ""); status != 404 {
+		t.Fatalf("confirming after the webhook: status %d, want 404", status)
+	}
+
+	// Events for intents of no booking are ignored
+	payload, _ = json.Marshal(payments.WebhookEvent{ID: "evt_" + bson.NewObjectID().Hex(), Type: payments.EventPaymentSucceeded, IntentID: "pi_unknown"})
+	status, body = f.sendWebhook(t, payload, f.provider.SignWebhook(payload, time.Now()))
+	if data, _ := body["data"].(map[string]interface{}); status != 200 || data["outcome"] != "ignored" {
+		t.Fatalf("unknown intent: status %d (%v), want 200 ignored", status, body)
+	}
+}
+
+// paidBooking stores a booking paid with one captured charge per amount
+func (f *bookingAccessFixture) paidBooking(t *testing.T, amounts ...int64) *models.Booking {
+	t.Helper()
+	ctx := context.Background()
+
+	booking := f.newBooking(t)
+	paid := 0.0
+	for _, amount := range amounts {
+		intent, err := f.provider.CreateIntent(ctx, payments.IntentRequest{Amount: amount, Currency: paymentCurrency, Reference: booking.BookingID})
+		if err != nil {
+			t.Fatalf("creating intent: %v", err)
+		}
+		if intent, err = f.provider.Capture(ctx, intent.ID); err != nil {
+			t.Fatalf("capturing intent: %v", err)
+		}
+		booking.Charges = append(booking.Charges, capturedCharge(intent))
+		paid += payments.FromMinorUnits(amount)
+	}
+	booking.PaymentStatus = "completed"
+	booking.PaymentProvider = f.provider.Name()
+	booking.PaymentIntentID = booking.Charges[0].IntentID
+	booking.Pricing.PaidAmount = paid
+
+	_, err := config.GetCollection("bookings").UpdateOne(ctx, bson.M{"_id": booking.ID}, bson.M{"$set": bson.M{
+		"payment_status":      booking.PaymentStatus,
+		"payment_provider":    booking.PaymentProvider,
+		"payment_intent_id":   booking.PaymentIntentID,
+		"pricing.paid_amount": paid,
+		"charges":             booking.Charges,
+	}})
+	if err != nil {
+		t.Fatalf("storing payment: %v", err)
+	}
+	return booking
+}
+
+// issueRefund records a refund on the booking and sends it to the provider
+func (f *bookingAccessFixture) issueRefund(t *testing.T, booking *models.Booking, amount float64) *models.Refund {
+	t.Helper()
+
+	refund := &models.Refund{ID: bson.NewObjectID(), Amount: amount, Percent: 100, Reason: "test", Status: models.RefundPending}
+	_, err := config.GetCollection("bookings").UpdateOne(context.Background(), bson.M{"_id": booking.ID}, bson.M{"$push": bson.M{"refunds": refund}})
+	if err != nil {
+		t.Fatalf("recording refund: %v", err)
+	}
+	f.handler.issueRefund(context.Background(), booking, refund)
+	return refund
+}
+
+func TestIssueRefundOverRefund(t *testing.T) {
+	f := newBookingAccessFixture(t)
+	booking := f.paidBooking(t, 2000)
+
+	refund := f.issueRefund(t, booking, 20.01)
+	if refund.Status != models.RefundFailed || len(refund.Parts) != 0 {
+		t.Fatalf("refund %s with parts %+v, want failed without reaching the provider", refund.Status, refund.Parts)
+	}
+	stored := loadBooking(t, booking.ID)
+	if stored.PaymentStatus != "completed" || stored.Refunds[0].Status != models.RefundFailed || stored.Charges[0].RefundedAmount != 0 {
+		t.Fatalf("booking %s with refund %s and %v refunded, want nothing returned", stored.PaymentStatus, stored.Refunds[0].Status, stored.Charges[0].RefundedAmount)
+	}
+
+	// The provider refuses to return more than it captured even if asked directly
+	_, err := f.provider.Refund(context.Background(), payments.RefundRequest{IntentID: booking.PaymentIntentID, Amount: 2001})
+	if !errors.Is(err, payments.ErrInvalidRefund) {
+		t.Fatalf("provider over-refund: %v, want ErrInvalidRefund", err)
+	}
+}
+
+func TestIssueRefundAcrossCharges(t *testing.T) {
+	f := newBookingAccessFixture(t)
+	booking := f.paidBooking(t, 2000, 500) // Paid, then charged a difference on exchange
+
+	refund := f.issueRefund(t, booking, 15)
+	if refund.Status != models.RefundSucceeded || len(refund.Parts) != 2 {
+		t.Fatalf("refund %s with parts %+v, want it split across both charges", refund.Status, refund.Parts)
+	}
+
+	stored := loadBooking(t, booking.ID)
+	if stored.PaymentStatus != "partially_refunded" {
+		t.Fatalf("booking %s, want partially_refunded", stored.PaymentStatus)
+	}
+	if stored.Charges[0].RefundedAmount != 10 || stored.Charges[1].RefundedAmount != 5 {
+		t.Fatalf("charges %+v, want 10 returned from the first and 5 from the second", stored.Charges)
+	}
+
+	// The rest comes from what the first charge has left
+	refund = f.issueRefund(t, stored, 10)
+	if refund.Status != models.RefundSucceeded || len(refund.Parts) != 1 || refund.Parts[0].IntentID != booking.Charges[0].IntentID {
+		t.Fatalf("refund %s with parts %+v, want it from the first charge", refund.Status, refund.Parts)
+	}
+	if stored := loadBooking(t, booking.ID); stored.PaymentStatus != "refunded" {
+		t.Fatalf("booking %s, want refunded", stored.PaymentStatus)
+	}
+}
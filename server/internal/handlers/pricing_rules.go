@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+type PricingRulesHandler struct {
+	pricingRuleRepo *db.PricingRuleRepository
+}
+
+func NewPricingRulesHandler() *PricingRulesHandler {
+	return &PricingRulesHandler{
+		pricingRuleRepo: db.NewPricingRuleRepository(),
+	}
+}
+
+// GetPricingRules lists the configured fee and tax rules
+func (h *PricingRulesHandler) GetPricingRules() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		rules, err := h.pricingRuleRepo.ListRules(ctx)
+		if err != nil {
+			log.Printf("Error finding pricing rules: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch pricing rules",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"rules":      rules,
+				"built_in":   models.DefaultPricingRule,
+				"count":      len(rules),
+				"precedence": []string{models.PricingScopeTheater, models.PricingScopeState, models.PricingScopeDefault},
+			},
+		})
+	}
+}
+
+// SavePricingRule creates or replaces the rule for a scope.
+// Theater admins may only set the rule of a theater they manage.
+func (h *PricingRulesHandler) SavePricingRule() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var rule models.PricingRule
+		if err := c.BodyParser(&rule); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid request body",
+			})
+		}
+
+		if err := rule.Validate(); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+
+		user := middleware.CurrentUser(c)
+		if !user.HasRole(models.RolePlatformAdmin) &&
+			(rule.Scope != models.PricingScopeTheater || !user.CanManageTheater(*rule.TheaterID)) {
+			return c.Status(403).JSON(fiber.Map{
+				"success": false,
+				"error":   "You may only set the pricing rule of a theater you manage",
+			})
+		}
+
+		now := time.Now()
+		rule.UpdatedBy = user.ID.Hex()
+		rule.CreatedAt = now
+		rule.UpdatedAt = now
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := h.pricingRuleRepo.SaveRule(ctx, &rule); err != nil {
+			log.Printf("Error saving pricing rule: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to save pricing rule",
+			})
+		}
+
+		log.Printf("[PRICING] %s rule %s saved by %s", rule.Scope, rule.ID.Hex(), user.Email)
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    rule,
+		})
+	}
+}
+
+// DeletePricingRule removes a rule so the next less specific rule applies
+func (h *PricingRulesHandler) DeletePricingRule() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ruleID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid pricing rule ID",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		deleted, err := h.pricingRuleRepo.DeleteRule(ctx, ruleID)
+		if err != nil {
+			log.Printf("Error deleting pricing rule: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to delete pricing rule",
+			})
+		}
+		if !deleted {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Pricing rule not found",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"message": "Pricing rule deleted",
+		})
+	}
+}
+
+// GetTheaterPricingRule returns the rule that currently prices tickets at a theater
+func (h *PricingRulesHandler) GetTheaterPricingRule() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		theaterID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid theater ID",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		rule, err := h.pricingRuleRepo.RuleForTheater(ctx, theaterID)
+		if err != nil {
+			log.Printf("Error resolving pricing rule: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch pricing rule",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    rule,
+		})
+	}
+}
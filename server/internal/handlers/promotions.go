@@ -0,0 +1,301 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+type PromotionsHandler struct {
+	showtimesCollection *mongo.Collection
+	promotionRepo       *db.PromotionRepository
+	pricingRuleRepo     *db.PricingRuleRepository
+}
+
+func NewPromotionsHandler() *PromotionsHandler {
+	return &PromotionsHandler{
+		showtimesCollection: config.GetCollection("showtimes"),
+		promotionRepo:       db.NewPromotionRepository(),
+		pricingRuleRepo:     db.NewPricingRuleRepository(),
+	}
+}
+
+// CreatePromotion creates a promo code
+func (h *PromotionsHandler) CreatePromotion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var promotion models.Promotion
+		if err := c.BodyParser(&promotion); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid request body",
+			})
+		}
+
+		promotion.Code = models.NormalizePromoCode(promotion.Code)
+		if err := promotion.Validate(); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+
+		now := time.Now()
+		promotion.ID = bson.ObjectID{}
+		promotion.UsedCount = 0
+		promotion.CreatedBy = middleware.CurrentUser(c).ID.Hex()
+		promotion.CreatedAt = now
+		promotion.UpdatedAt = now
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := h.promotionRepo.CreatePromotion(ctx, &promotion); err != nil {
+			if errors.Is(err, db.ErrPromoCodeExists) {
+				return c.Status(409).JSON(fiber.Map{
+					"success": false,
+					"error":   "Promo code already exists",
+				})
+			}
+			log.Printf("Error creating promotion: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to create promotion",
+			})
+		}
+
+		return c.Status(201).JSON(fiber.Map{
+			"success": true,
+			"data":    promotion,
+		})
+	}
+}
+
+// GetPromotions lists every promo code with its usage
+func (h *PromotionsHandler) GetPromotions() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		promotions, err := h.promotionRepo.ListPromotions(ctx)
+		if err != nil {
+			log.Printf("Error finding promotions: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch promotions",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    promotions,
+			"count":   len(promotions),
+		})
+	}
+}
+
+// UpdatePromotion replaces the rules of a promo code. The code itself cannot change.
+func (h *PromotionsHandler) UpdatePromotion() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		promotionID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid promotion ID",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		existing, err := h.promotionRepo.FindPromotionByID(ctx, promotionID)
+		if err != nil {
+			log.Printf("Error finding promotion: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch promotion",
+			})
+		}
+		if existing == nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Promotion not found",
+			})
+		}
+
+		promotion := *existing
+		if err := c.BodyParser(&promotion); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid request body",
+			})
+		}
+		promotion.ID = existing.ID
+		promotion.Code = existing.Code
+		promotion.UsedCount = existing.UsedCount
+		promotion.CreatedBy = existing.CreatedBy
+		promotion.CreatedAt = existing.CreatedAt
+		promotion.UpdatedAt = time.Now()
+
+		if err := promotion.Validate(); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+
+		if _, err := h.promotionRepo.UpdatePromotion(ctx, &promotion); err != nil {
+			log.Printf("Error updating promotion: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to update promotion",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    promotion,
+		})
+	}
+}
+
+// ValidatePromoCode previews the discount a promo code gives on a seat selection.
+// Nothing is redeemed; the code is checked again when the booking is created.
+func (h *PromotionsHandler) ValidatePromoCode() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request struct {
+			Code       string   `json:"code"`
+			ShowtimeID string   `json:"showtime_id"`
+			SeatIDs    []string `json:"seat_ids"`
+		}
+		if err := c.BodyParser(&request); err != nil || request.Code == "" || len(request.SeatIDs) == 0 {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "code and seat_ids are required",
+			})
+		}
+
+		showtimeID, err := bson.ObjectIDFromHex(request.ShowtimeID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid showtime ID",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		showtime := &models.Showtime{}
+		if err := h.showtimesCollection.FindOne(ctx, bson.M{"_id": showtimeID}).Decode(showtime); err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Showtime not found",
+			})
+		}
+
+		booking := &models.Booking{}
+		for _, seatID := range models.UniqueSeatIDs(request.SeatIDs) {
+			seat := showtime.GetSeatByID(seatID)
+			if seat == nil {
+				return c.Status(400).JSON(fiber.Map{
+					"success": false,
+					"error":   fmt.Sprintf("seat %s not found", seatID),
+				})
+			}
+			booking.AddSeat(seat.SeatID, seat.RowID, seat.SeatNumber, seat.SeatType, seat.Price)
+		}
+
+		rule, err := h.pricingRuleRepo.RuleForTheater(ctx, showtime.TheaterID)
+		if err != nil {
+			log.Printf("Error resolving pricing rule: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to validate promo code",
+			})
+		}
+		booking.CalculatePricing(rule, 0.0)
+
+		promotion, err := eligiblePromotion(ctx, h.promotionRepo, request.Code, booking, showtime, middleware.CurrentUser(c))
+		if err != nil {
+			if errors.Is(err, models.ErrPromotionNotApplicable) {
+				return c.Status(422).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			}
+			log.Printf("Error validating promo code: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to validate promo code",
+			})
+		}
+
+		booking.ApplyPromotion(promotion)
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"code":        promotion.Code,
+				"description": promotion.Description,
+				"discount":    booking.Promotion.Discount,
+				"pricing":     booking.Pricing,
+			},
+		})
+	}
+}
+
+// eligiblePromotion finds a promo code and checks it against a booking and the user's past redemptions
+func eligiblePromotion(ctx context.Context, repo *db.PromotionRepository, code string, booking *models.Booking, showtime *models.Showtime, user *models.User) (*models.Promotion, error) {
+	promotion, err := repo.FindPromotionByCode(ctx, models.NormalizePromoCode(code))
+	if err != nil {
+		return nil, err
+	}
+	if promotion == nil {
+		return nil, fmt.Errorf("%w: promo code not found", models.ErrPromotionNotApplicable)
+	}
+
+	if err := promotion.CheckEligibility(booking, showtime, time.Now()); err != nil {
+		return nil, err
+	}
+
+	if promotion.PerUserLimit > 0 {
+		used, err := repo.CountUserRedemptions(ctx, promotion.ID, models.OwnerIDs(user))
+		if err != nil {
+			return nil, err
+		}
+		if used >= int64(promotion.PerUserLimit) {
+			return nil, fmt.Errorf("%w: you have already used this promo code", models.ErrPromotionNotApplicable)
+		}
+	}
+
+	return promotion, nil
+}
+
+// redeemPromotion applies a promo code to a priced booking inside the booking transaction
+func redeemPromotion(sc context.Context, repo *db.PromotionRepository, code string, booking *models.Booking, showtime *models.Showtime, user *models.User) error {
+	promotion, err := eligiblePromotion(sc, repo, code, booking, showtime, user)
+	if err != nil {
+		return err
+	}
+
+	redeemed, err := repo.Redeem(sc, promotion.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !redeemed {
+		return fmt.Errorf("%w: promo code has been fully redeemed", models.ErrPromotionNotApplicable)
+	}
+
+	booking.ApplyPromotion(promotion)
+	return nil
+}
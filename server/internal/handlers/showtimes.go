@@ -2,12 +2,17 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
 	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/internal/services"
 	"github.com/tejas161/Cinema-Flix/models"
 	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -16,12 +21,18 @@ import (
 type ShowtimesHandler struct {
 	showtimesCollection *mongo.Collection
 	theatersCollection  *mongo.Collection
+	showtimeRepo        *db.ShowtimeRepository
+	pricingRuleRepo     *db.PricingRuleRepository
+	priceChangeRepo     *db.PriceChangeRepository
 }
 
 func NewShowtimesHandler() *ShowtimesHandler {
 	return &ShowtimesHandler{
 		showtimesCollection: config.GetCollection("showtimes"),
 		theatersCollection:  config.GetCollection("theaters"),
+		showtimeRepo:        db.NewShowtimeRepository(),
+		pricingRuleRepo:     db.NewPricingRuleRepository(),
+		priceChangeRepo:     db.NewPriceChangeRepository(),
 	}
 }
 
@@ -36,6 +47,14 @@ func (h *ShowtimesHandler) CreateShowtime() fiber.Handler {
 			})
 		}
 
+		// Theater admins may only schedule shows in their own theaters
+		if user := middleware.CurrentUser(c); user == nil || !user.CanManageTheater(showtimeData.TheaterID) {
+			return c.Status(403).JSON(fiber.Map{
+				"success": false,
+				"error":   "You do not manage this theater",
+			})
+		}
+
 		// Validate theater and screen exist
 		if err := h.validateTheaterAndScreen(showtimeData.TheaterID, showtimeData.ScreenID); err != nil {
 			return c.Status(400).JSON(fiber.Map{
@@ -44,7 +63,14 @@ func (h *ShowtimesHandler) CreateShowtime() fiber.Handler {
 			})
 		}
 
-		// Get screen details to initialize seats
+		// Get theater and screen details to initialize seats
+		theater, err := h.getTheaterByID(showtimeData.TheaterID)
+		if err != nil {
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to get theater details",
+			})
+		}
 		screen, err := h.getScreenDetails(showtimeData.TheaterID, showtimeData.ScreenID)
 		if err != nil {
 			return c.Status(500).JSON(fiber.Map{
@@ -53,9 +79,9 @@ func (h *ShowtimesHandler) CreateShowtime() fiber.Handler {
 			})
 		}
 
-		// Initialize seats for the showtime
-		showtimeData.Seats = h.initializeSeats(screen)
-		showtimeData.TotalSeats = screen.TotalSeats
+		// Initialize seats priced for the show's local time band
+		showtimeData.Seats = theater.InitializeSeats(screen, showtimeData.ShowTime)
+		showtimeData.TotalSeats = len(showtimeData.Seats)
 		showtimeData.BookedSeats = 0
 
 		// Set timestamps
@@ -66,6 +92,17 @@ func (h *ShowtimesHandler) CreateShowtime() fiber.Handler {
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		defer cancel()
 
+		// Price the seat categories from the seats themselves with the theater's fee and tax rule
+		rule, err := h.pricingRuleRepo.RuleFor(ctx, theater.ID, theater.State)
+		if err != nil {
+			log.Printf("Error resolving pricing rule: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to price showtime",
+			})
+		}
+		showtimeData.Pricing = rule.ShowPricingForSeats(showtimeData.Seats)
+
 		result, err := h.showtimesCollection.InsertOne(ctx, showtimeData)
 		if err != nil {
 			log.Printf("Error creating showtime: %v", err)
@@ -213,41 +250,6 @@ func (h *ShowtimesHandler) getTheaterByID(theaterID bson.ObjectID) (*models.Thea
 	return &theater, nil
 }
 
-// initializeSeats creates seat array for a new showtime
-func (h *ShowtimesHandler) initializeSeats(screen *models.Screen) []models.Seat {
-	var seats []models.Seat
-
-	for _, row := range screen.SeatLayout.Rows {
-		for seatNum := 1; seatNum <= row.SeatCount; seatNum++ {
-			seatID := fmt.Sprintf("%s%d", row.RowID, seatNum)
-
-			// Determine price based on time (simplified logic)
-			var price float64
-			switch row.RowType {
-			case "premium":
-				price = row.Price.Evening // Use evening price as default
-			case "regular":
-				price = row.Price.Afternoon // Use afternoon price as default
-			default:
-				price = row.Price.Afternoon
-			}
-
-			seat := models.Seat{
-				SeatID:     seatID,
-				RowID:      row.RowID,
-				SeatNumber: seatNum,
-				SeatType:   row.RowType,
-				Status:     "available",
-				Price:      price,
-			}
-
-			seats = append(seats, seat)
-		}
-	}
-
-	return seats
-}
-
 // buildSeatLayoutResponse builds seat layout for frontend
 func (h *ShowtimesHandler) buildSeatLayoutResponse(seats []models.Seat, layout models.SeatLayout) map[string]interface{} {
 	// Group seats by row
@@ -256,16 +258,24 @@ func (h *ShowtimesHandler) buildSeatLayoutResponse(seats []models.Seat, layout m
 		seatsByRow[seat.RowID] = append(seatsByRow[seat.RowID], seat)
 	}
 
-	// Build response
+	// Build response; the grid places every seat, aisle and gap where the client draws it
 	response := map[string]interface{}{
 		"rows":   seatsByRow,
 		"layout": layout,
+		"grid":   models.BuildSeatGrid(seats),
 		"legend": map[string]string{
 			"available":   "Available",
+			"held":        "Held for Payment",
 			"booked":      "Booked",
 			"blocked":     "Temporarily Blocked",
 			"maintenance": "Under Maintenance",
 		},
+		"seat_kinds": map[string]string{
+			models.SeatKindStandard:   "Standard",
+			models.SeatKindWheelchair: "Wheelchair Space",
+			models.SeatKindCompanion:  "Companion Seat",
+			models.SeatKindLoveseat:   "Loveseat (sold in pairs)",
+		},
 	}
 
 	return response
@@ -315,63 +325,289 @@ func (h *ShowtimesHandler) UpdateSeatStatus() fiber.Handler {
 			})
 		}
 
-		// Update seat status based on action
-		success := true
-		for _, seatID := range request.SeatIDs {
+		// Theater staff may only change seats in their own theaters
+		if user := middleware.CurrentUser(c); user == nil || !user.CanManageTheater(showtime.TheaterID) {
+			return c.Status(403).JSON(fiber.Map{
+				"success": false,
+				"error":   "You do not manage this theater",
+			})
+		}
+
+		if request.Action != "block" && request.Action != "book" && request.Action != "release" {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid action",
+			})
+		}
+
+		// Blocking or booking seats must not strand a single seat either
+		if request.Action == "block" || request.Action == "book" {
+			// Loveseats are sold in pairs, so both halves change together
+			if unpaired := showtime.UnpairedLoveseats(request.SeatIDs); len(unpaired) > 0 {
+				return c.Status(400).JSON(fiber.Map{
+					"success": false,
+					"error":   fmt.Sprintf("loveseat %s must be updated together with its pair", unpaired[0]),
+				})
+			}
+
+			theater, err := h.getTheaterByID(showtime.TheaterID)
+			if err != nil {
+				log.Printf("Error finding theater: %v", err)
+				return c.Status(500).JSON(fiber.Map{
+					"success": false,
+					"error":   "Failed to fetch theater details",
+				})
+			}
+
+			var gapErr *models.SeatGapError
+			if errors.As(showtime.CheckSeatGaps(request.SeatIDs, theater.EffectiveSeatGapPolicy()), &gapErr) {
+				return seatGapResponse(c, gapErr)
+			}
+		}
+
+		session, err := config.MongoClient.StartSession()
+		if err != nil {
+			log.Printf("Error starting session: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to update showtime",
+			})
+		}
+		defer session.EndSession(ctx)
+
+		// Apply the action with conditional per-seat updates; all seats change or none do
+		_, err = session.WithTransaction(ctx, func(sc context.Context) (interface{}, error) {
 			switch request.Action {
 			case "block":
-				if !showtime.BlockSeat(seatID, 15*time.Minute) {
-					success = false
-				}
+				return nil, h.showtimeRepo.BlockSeats(sc, showtimeID, request.SeatIDs, time.Now().Add(15*time.Minute))
 			case "book":
-				if !showtime.BookSeat(seatID, request.UserID) {
-					success = false
+				return nil, h.showtimeRepo.BookSeats(sc, showtimeID, request.SeatIDs, request.UserID)
+			default:
+				released, err := h.showtimeRepo.ReleaseSeats(sc, showtimeID, request.SeatIDs)
+				if err != nil {
+					return nil, err
 				}
-			case "release":
-				if !showtime.ReleaseSeat(seatID) {
-					success = false
+				if released != len(models.UniqueSeatIDs(request.SeatIDs)) {
+					return nil, db.ErrSeatsUnavailable
 				}
-			default:
+				return nil, nil
+			}
+		})
+
+		if err != nil {
+			if errors.Is(err, db.ErrSeatsUnavailable) {
 				return c.Status(400).JSON(fiber.Map{
 					"success": false,
-					"error":   "Invalid action",
+					"error":   "Failed to update some seats",
 				})
 			}
+			log.Printf("Error updating showtime: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to update showtime",
+			})
+		}
+
+		// Reload counts after the update
+		if err := h.showtimesCollection.FindOne(ctx, bson.M{"_id": showtimeID}).Decode(&showtime); err != nil {
+			log.Printf("Error reloading showtime: %v", err)
 		}
 
-		if !success {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"showtime_id":     showtimeID.Hex(),
+				"updated_seats":   request.SeatIDs,
+				"action":          request.Action,
+				"available_seats": showtime.AvailableSeats(),
+			},
+		})
+	}
+}
+
+// seatGapResponse explains which seats a selection would strand and what to pick instead
+func seatGapResponse(c *fiber.Ctx, gapErr *models.SeatGapError) error {
+	return c.Status(422).JSON(fiber.Map{
+		"success":        false,
+		"error":          "Your selection would leave a single empty seat. Please choose seats that keep the row together.",
+		"stranded_seats": gapErr.SeatIDs,
+		"suggestions":    gapErr.Suggestions,
+	})
+}
+
+// GetPriceChanges returns the audit log of a showtime's dynamic price changes
+func (h *ShowtimesHandler) GetPriceChanges() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		showtimeID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
 			return c.Status(400).JSON(fiber.Map{
 				"success": false,
-				"error":   "Failed to update some seats",
+				"error":   "Invalid showtime ID",
 			})
 		}
 
-		// Update showtime in database
-		update := bson.M{
-			"$set": bson.M{
-				"seats":        showtime.Seats,
-				"booked_seats": showtime.BookedSeats,
-				"updated_at":   time.Now(),
+		limit, _ := strconv.Atoi(c.Query("limit", "50"))
+		if limit < 1 || limit > 200 {
+			limit = 50
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		showtime, err := h.showtimeRepo.FindShowtimeByID(ctx, showtimeID)
+		if err != nil {
+			log.Printf("Error finding showtime: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch showtime",
+			})
+		}
+		if showtime == nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Showtime not found",
+			})
+		}
+
+		if user := middleware.CurrentUser(c); user == nil || !user.CanManageTheater(showtime.TheaterID) {
+			return c.Status(403).JSON(fiber.Map{
+				"success": false,
+				"error":   "You do not manage this theater",
+			})
+		}
+
+		changes, err := h.priceChangeRepo.FindShowtimePriceChanges(ctx, showtimeID, int64(limit))
+		if err != nil {
+			log.Printf("Error finding price changes: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch price changes",
+			})
+		}
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"showtime_id":      showtimeID.Hex(),
+				"price_multiplier": showtime.PriceMultiplier,
+				"changes":          changes,
+				"count":            len(changes),
 			},
+		})
+	}
+}
+
+// GetBestSeats suggests the best available seats for a party; nothing is held until booking
+func (h *ShowtimesHandler) GetBestSeats() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		showtimeID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid showtime ID",
+			})
 		}
 
-		_, err = h.showtimesCollection.UpdateOne(ctx, bson.M{"_id": showtimeID}, update)
+		var request struct {
+			Quantity int    `json:"quantity"`
+			SeatType string `json:"seat_type"` // Optional: premium, regular
+		}
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid request body",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		showtime, err := h.showtimeRepo.FindShowtimeByID(ctx, showtimeID)
 		if err != nil {
-			log.Printf("Error updating showtime: %v", err)
+			log.Printf("Error finding showtime: %v", err)
 			return c.Status(500).JSON(fiber.Map{
 				"success": false,
-				"error":   "Failed to update showtime",
+				"error":   "Failed to fetch showtime",
+			})
+		}
+		if showtime == nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Showtime not found",
+			})
+		}
+		if !showtime.IsAvailable() {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Showtime is not available for booking",
 			})
 		}
 
+		selection, err := showtime.BestAvailableSeats(request.Quantity, request.SeatType)
+		if err != nil {
+			status := 400
+			if errors.Is(err, models.ErrNotEnoughSeats) {
+				status = 409
+			}
+			return c.Status(status).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+
+		total := 0.0
+		for _, seat := range selection.Seats {
+			total += seat.Price
+		}
+
 		return c.JSON(fiber.Map{
 			"success": true,
 			"data": map[string]interface{}{
-				"showtime_id":     showtimeID.Hex(),
-				"updated_seats":   request.SeatIDs,
-				"action":          request.Action,
-				"available_seats": showtime.AvailableSeats(),
+				"showtime_id": showtimeID.Hex(),
+				"selection":   selection,
+				"base_amount": total,
 			},
 		})
 	}
 }
+
+// GetSeatMapSVG renders the showtime's current seat map as an SVG image
+func (h *ShowtimesHandler) GetSeatMapSVG() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		showtimeID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid showtime ID",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		showtime, err := h.showtimeRepo.FindShowtimeByID(ctx, showtimeID)
+		if err != nil {
+			log.Printf("Error finding showtime: %v", err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to fetch showtime",
+			})
+		}
+		if showtime == nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Showtime not found",
+			})
+		}
+
+		// The map still renders without a screen name if the screen was removed
+		screenName := ""
+		if screen, err := h.getScreenDetails(showtime.TheaterID, showtime.ScreenID); err == nil {
+			screenName = screen.Name
+		}
+
+		c.Set(fiber.HeaderContentType, "image/svg+xml")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		return c.Send(services.RenderSeatMapSVG(showtime, screenName))
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
 	"github.com/tejas161/Cinema-Flix/internal/config"
 	"github.com/tejas161/Cinema-Flix/models"
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -18,6 +19,7 @@ type TheatersHandler struct {
 	theatersCollection  *mongo.Collection
 	showtimesCollection *mongo.Collection
 	bookingsCollection  *mongo.Collection
+	pricingRuleRepo     *db.PricingRuleRepository
 }
 
 func NewTheatersHandler() *TheatersHandler {
@@ -25,6 +27,7 @@ func NewTheatersHandler() *TheatersHandler {
 		theatersCollection:  config.GetCollection("theaters"),
 		showtimesCollection: config.GetCollection("showtimes"),
 		bookingsCollection:  config.GetCollection("bookings"),
+		pricingRuleRepo:     db.NewPricingRuleRepository(),
 	}
 }
 
@@ -220,14 +223,56 @@ func (h *TheatersHandler) CreateTheater() fiber.Handler {
 			})
 		}
 
+		if theaterData.Timezone != "" {
+			if _, err := time.LoadLocation(theaterData.Timezone); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"success": false,
+					"error":   "Unknown timezone " + theaterData.Timezone,
+				})
+			}
+		}
+		if theaterData.PricingSchedule != nil {
+			if err := theaterData.PricingSchedule.Validate(); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			}
+		}
+		if theaterData.DynamicPricing != nil {
+			if err := theaterData.DynamicPricing.Validate(); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			}
+		}
+
+		if theaterData.CancellationPolicy != nil {
+			if err := theaterData.CancellationPolicy.Validate(); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"success": false,
+					"error":   err.Error(),
+				})
+			}
+		}
+
 		// Set timestamps
 		now := time.Now()
 		theaterData.CreatedAt = now
 		theaterData.UpdatedAt = now
 
-		// Generate IDs for screens
+		// Generate IDs for screens and count their seats from the layout
 		for i := range theaterData.Screens {
-			theaterData.Screens[i].ID = bson.NewObjectID()
+			screen := &theaterData.Screens[i]
+			if err := screen.SeatLayout.Validate(); err != nil {
+				return c.Status(400).JSON(fiber.Map{
+					"success": false,
+					"error":   "Screen " + screen.Name + ": " + err.Error(),
+				})
+			}
+			screen.ID = bson.NewObjectID()
+			screen.TotalSeats = screen.SeatLayout.SeatCount()
 		}
 
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -0,0 +1,232 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/internal/services"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+const ticketQRCodeSize = 320
+
+var errTicketUnavailable = errors.New("ticket is not available for this booking")
+
+type TicketsHandler struct {
+	bookingsCollection *mongo.Collection
+	bookingRepo        *db.BookingRepository
+	tickets            *services.TicketService
+}
+
+func NewTicketsHandler(tickets *services.TicketService) *TicketsHandler {
+	return &TicketsHandler{
+		bookingsCollection: config.GetCollection("bookings"),
+		bookingRepo:        db.NewBookingRepository(),
+		tickets:            tickets,
+	}
+}
+
+// GetTicketQRCode returns the booking's ticket as a QR code PNG
+func (h *TicketsHandler) GetTicketQRCode() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		booking, err := findTicketBooking(c, h.bookingsCollection)
+		if err != nil {
+			return ticketErrorResponse(c, err)
+		}
+
+		png, err := h.tickets.TicketQRCode(booking, ticketQRCodeSize)
+		if err != nil {
+			log.Printf("Error rendering ticket for booking %s: %v", booking.BookingID, err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to render ticket",
+			})
+		}
+
+		c.Set(fiber.HeaderContentType, "image/png")
+		c.Set(fiber.HeaderCacheControl, "private, no-store")
+		return c.Send(png)
+	}
+}
+
+// CheckIn admits the holder of a scanned ticket.
+// The ticket signature is verified, then the booking is checked for being paid and
+// active, and each seat is recorded as checked in exactly once.
+func (h *TicketsHandler) CheckIn() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var request struct {
+			Token   string   `json:"token"`
+			SeatIDs []string `json:"seat_ids"` // Defaults to every seat on the ticket
+		}
+		if err := c.BodyParser(&request); err != nil || request.Token == "" {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "token is required",
+			})
+		}
+
+		claims, err := h.tickets.VerifyTicket(request.Token)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid or expired ticket",
+			})
+		}
+
+		bookingID, err := bson.ObjectIDFromHex(claims.BookingID)
+		if err != nil {
+			return c.Status(400).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid or expired ticket",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		var booking models.Booking
+		if err := h.bookingsCollection.FindOne(ctx, bson.M{"_id": bookingID}).Decode(&booking); err != nil {
+			return c.Status(404).JSON(fiber.Map{
+				"success": false,
+				"error":   "Booking not found",
+			})
+		}
+
+		staff := middleware.CurrentUser(c)
+		if !staff.CanManageTheater(booking.TheaterID) {
+			return c.Status(403).JSON(fiber.Map{
+				"success": false,
+				"error":   "You do not manage this theater",
+			})
+		}
+
+		if !booking.IsValidTicket() {
+			return c.Status(409).JSON(fiber.Map{
+				"success": false,
+				"error":   "Ticket is not valid",
+				"data": map[string]interface{}{
+					"booking_id":     booking.BookingID,
+					"booking_status": booking.BookingStatus,
+					"payment_status": booking.PaymentStatus,
+				},
+			})
+		}
+
+		// Only seats that are both on the ticket and still part of the booking are admitted
+		seatIDs := models.UniqueSeatIDs(request.SeatIDs)
+		if len(seatIDs) == 0 {
+			seatIDs = claims.SeatIDs
+		}
+		onTicket := make(map[string]bool, len(claims.SeatIDs))
+		for _, seatID := range claims.SeatIDs {
+			onTicket[seatID] = true
+		}
+		bookedSeats := make(map[string]models.BookedSeat, len(booking.Seats))
+		for _, seat := range booking.Seats {
+			bookedSeats[seat.SeatID] = seat
+		}
+
+		var admit, alreadyScanned []string
+		for _, seatID := range seatIDs {
+			seat, booked := bookedSeats[seatID]
+			if !onTicket[seatID] || !booked {
+				return c.Status(409).JSON(fiber.Map{
+					"success": false,
+					"error":   "Seat " + seatID + " is not valid on this ticket",
+				})
+			}
+			if seat.CheckedInAt != nil {
+				alreadyScanned = append(alreadyScanned, seatID)
+				continue
+			}
+			admit = append(admit, seatID)
+		}
+
+		if len(alreadyScanned) > 0 {
+			return c.Status(409).JSON(fiber.Map{
+				"success": false,
+				"error":   "Ticket was already scanned",
+				"data": map[string]interface{}{
+					"booking_id": booking.BookingID,
+					"seat_ids":   alreadyScanned,
+				},
+			})
+		}
+
+		now := time.Now()
+		ok, err := h.bookingRepo.CheckInSeats(ctx, booking.ID, admit, staff.ID.Hex(), now)
+		if err != nil {
+			log.Printf("Error checking in booking %s: %v", booking.BookingID, err)
+			return c.Status(500).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to check in",
+			})
+		}
+		if !ok {
+			// Scanned at another door or cancelled since it was loaded
+			return c.Status(409).JSON(fiber.Map{
+				"success": false,
+				"error":   "Ticket was already scanned or is no longer valid",
+			})
+		}
+
+		log.Printf("[CHECKIN] Booking %s seats %v checked in by %s", booking.BookingID, admit, staff.Email)
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data": map[string]interface{}{
+				"booking_id":    booking.BookingID,
+				"movie_id":      booking.MovieID,
+				"show_time":     booking.ShowTime,
+				"seat_ids":      admit,
+				"checked_in_at": now,
+				"message":       "Check-in successful",
+			},
+		})
+	}
+}
+
+// findTicketBooking loads a paid, active booking the current user may see
+func findTicketBooking(c *fiber.Ctx, bookings *mongo.Collection) (*models.Booking, error) {
+	bookingID, err := bson.ObjectIDFromHex(c.Params("id"))
+	if err != nil {
+		return nil, errBookingNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var booking models.Booking
+	err = bookings.FindOne(ctx, bson.M{"_id": bookingID}).Decode(&booking)
+	if err != nil || !canAccessBooking(middleware.CurrentUser(c), &booking) {
+		return nil, errBookingNotFound
+	}
+
+	if !booking.IsValidTicket() {
+		return nil, errTicketUnavailable
+	}
+
+	return &booking, nil
+}
+
+// ticketErrorResponse maps ticket lookup errors onto HTTP responses
+func ticketErrorResponse(c *fiber.Ctx, err error) error {
+	if errors.Is(err, errTicketUnavailable) {
+		return c.Status(409).JSON(fiber.Map{
+			"success": false,
+			"error":   "Tickets are only available for paid, active bookings",
+		})
+	}
+	return c.Status(404).JSON(fiber.Map{
+		"success": false,
+		"error":   "Booking not found",
+	})
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// UpdateUserRole assigns a role and managed theaters to a user
+// This endpoint is restricted to platform admins by the router
+func UpdateUserRole() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := bson.ObjectIDFromHex(c.Params("id"))
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid user ID",
+			})
+		}
+
+		var request struct {
+			Role              string   `json:"role"`
+			ManagedTheaterIDs []string `json:"managed_theater_ids"`
+		}
+
+		if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid request body",
+			})
+		}
+
+		if !models.IsValidRole(request.Role) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid role",
+			})
+		}
+
+		var theaterIDs []bson.ObjectID
+		for _, idStr := range request.ManagedTheaterIDs {
+			theaterID, err := bson.ObjectIDFromHex(idStr)
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"success": false,
+					"error":   "Invalid theater ID: " + idStr,
+				})
+			}
+			theaterIDs = append(theaterIDs, theaterID)
+		}
+
+		if (request.Role == models.RoleTheaterStaff || request.Role == models.RoleTheaterAdmin) && len(theaterIDs) == 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Theater roles require at least one managed theater",
+			})
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		user, err := db.NewUserRepository().UpdateUserRole(ctx, userID, request.Role, theaterIDs)
+		if err != nil {
+			log.Printf("[AUTH] ERROR: Failed to update user role: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to update user role",
+			})
+		}
+
+		if user == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"success": false,
+				"error":   "User not found",
+			})
+		}
+
+		log.Printf("[AUTH] User %s role set to %s", user.Email, user.Role)
+
+		return c.JSON(fiber.Map{
+			"success": true,
+			"user": fiber.Map{
+				"id":                  user.ID.Hex(),
+				"email":               user.Email,
+				"name":                user.Name,
+				"role":                user.Role,
+				"managed_theater_ids": user.ManagedTheaterIDs,
+			},
+		})
+	}
+}
@@ -2,46 +2,41 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/services"
+	"github.com/tejas161/Cinema-Flix/models"
 )
 
 // RequireAuth middleware validates user authentication
-// Expects user ID to be passed in X-User-ID header
-func RequireAuth() fiber.Handler {
+// Expects a signed session token in the session cookie or an Authorization: Bearer header
+func RequireAuth(sessions *services.SessionService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Get user information from auth headers
-		// Get userId from JSON body (googleId or id field)
-		var userPayload struct {
-			GoogleID string `json:"googleId"`
-			ID       string `json:"id"`
-		}
-		_ = c.BodyParser(&userPayload)
-		userID := userPayload.GoogleID
-		if userID == "" {
-			userID = userPayload.ID
-		}
-
-		log.Printf("google user id %s", userID);
-
-		if userID == "" {
-			return c.Status(401).JSON(fiber.Map{
+		token := SessionToken(c, sessions)
+		if token == "" {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"success": false,
 				"error":   "User authentication required",
 			})
 		}
 
-		
-		// Verify user exists in database
-		userRepo := db.NewUserRepository()
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		user, err := userRepo.FindUserByID(ctx, userID)
+		session, user, err := sessions.ValidateSession(ctx, token)
 		if err != nil {
+			if errors.Is(err, services.ErrSessionInvalid) {
+				log.Printf("[AUTH] Rejected invalid or expired session from %s", c.IP())
+				ClearSessionCookie(c, sessions)
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"success": false,
+					"error":   "Invalid authentication",
+				})
+			}
 			log.Printf("[AUTH] Database error during auth check: %v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
@@ -49,17 +44,10 @@ func RequireAuth() fiber.Handler {
 			})
 		}
 
-		if user == nil {
-			log.Printf("[AUTH] Invalid user ID in auth header: %s", userID)
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"success": false,
-				"error":   "Invalid authentication",
-			})
-		}
-
 		// Store user info in context for use in handlers
 		c.Locals("user", user)
 		c.Locals("userID", user.ID.Hex())
+		c.Locals("session", session)
 
 		log.Printf("[AUTH] Authenticated user: %s (%s)", user.Name, user.Email)
 
@@ -69,48 +57,103 @@ func RequireAuth() fiber.Handler {
 
 // OptionalAuth middleware validates user authentication if provided
 // Similar to RequireAuth but doesn't fail if no auth is provided
-func OptionalAuth() fiber.Handler {
+func OptionalAuth(sessions *services.SessionService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		var userPayload struct {
-			GoogleID string `json:"googleId"`
-			ID       string `json:"id"`
-		}
-		_ = c.BodyParser(&userPayload)
-		log.Printf("User payload: %v", userPayload);
-		userID := userPayload.GoogleID
-		if userID == "" {
-			userID = userPayload.ID
-		}
-
-		if userID == "" {
+		token := SessionToken(c, sessions)
+		if token == "" {
 			// No authentication provided, continue without user context
 			return c.Next()
 		}
 
-		// Verify user exists in database
-		userRepo := db.NewUserRepository()
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
 
-		user, err := userRepo.FindUserByID(ctx, userID)
+		session, user, err := sessions.ValidateSession(ctx, token)
 		if err != nil {
-			log.Printf("[AUTH] Database error during optional auth check: %v", err)
-			// Continue without authentication on database error
-			return c.Next()
-		}
-
-		if user == nil {
-			log.Printf("[AUTH] Invalid user ID in optional auth header: %s", userID)
-			// Continue without authentication for invalid user ID
+			log.Printf("[AUTH] Ignoring optional session: %v", err)
+			// Continue without authentication for an invalid session
 			return c.Next()
 		}
 
 		// Store user info in context for use in handlers
 		c.Locals("user", user)
 		c.Locals("userID", user.ID.Hex())
+		c.Locals("session", session)
 
 		log.Printf("[AUTH] Optionally authenticated user: %s (%s)", user.Name, user.Email)
 
 		return c.Next()
 	}
 }
+
+// RequireRole middleware restricts a route to users holding one of the given roles
+// Must be registered after RequireAuth
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		user := CurrentUser(c)
+		if user == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error":   "User authentication required",
+			})
+		}
+
+		if !user.HasRole(roles...) {
+			log.Printf("[AUTH] User %s with role %s denied access to %s %s", user.Email, user.EffectiveRole(), c.Method(), c.Path())
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"success": false,
+				"error":   "Insufficient permissions",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// CurrentUser returns the authenticated user stored by RequireAuth/OptionalAuth
+func CurrentUser(c *fiber.Ctx) *models.User {
+	user, _ := c.Locals("user").(*models.User)
+	return user
+}
+
+// SessionToken extracts the session token from the cookie or bearer header
+func SessionToken(c *fiber.Ctx, sessions *services.SessionService) string {
+	if token := c.Cookies(sessions.Config().CookieName); token != "" {
+		return token
+	}
+
+	authHeader := c.Get(fiber.HeaderAuthorization)
+	if strings.HasPrefix(authHeader, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authHeader, "Bearer "))
+	}
+
+	return ""
+}
+
+// SetSessionCookie stores the session token in an HttpOnly cookie
+func SetSessionCookie(c *fiber.Ctx, sessions *services.SessionService, token string, expiresAt time.Time) {
+	cfg := sessions.Config()
+	c.Cookie(&fiber.Cookie{
+		Name:     cfg.CookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expiresAt,
+		HTTPOnly: true,
+		Secure:   cfg.CookieSecure,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
+
+// ClearSessionCookie removes the session cookie from the browser
+func ClearSessionCookie(c *fiber.Ctx, sessions *services.SessionService) {
+	cfg := sessions.Config()
+	c.Cookie(&fiber.Cookie{
+		Name:     cfg.CookieName,
+		Value:    "",
+		Path:     "/",
+		Expires:  time.Unix(0, 0),
+		HTTPOnly: true,
+		Secure:   cfg.CookieSecure,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+}
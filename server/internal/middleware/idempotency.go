@@ -0,0 +1,135 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/models"
+)
+
+const (
+	IdempotencyKeyHeader = "Idempotency-Key"
+
+	idempotencyTTL = 24 * time.Hour
+
+	// idempotencyLockTimeout is how long a key may stay in processing before
+	// it is considered abandoned (e.g. the server restarted mid-request)
+	idempotencyLockTimeout = 2 * time.Minute
+)
+
+// Idempotency middleware replays the stored response when a request is retried
+// with the same Idempotency-Key header. Keys are scoped per user, so it must be
+// registered after RequireAuth. Requests without the header pass straight through.
+func Idempotency() fiber.Handler {
+	repo := db.NewIdempotencyRepository()
+
+	return func(c *fiber.Ctx) error {
+		key := c.Get(IdempotencyKeyHeader)
+		if key == "" {
+			return c.Next()
+		}
+
+		if len(key) > 255 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Idempotency-Key must be at most 255 characters",
+			})
+		}
+
+		user := CurrentUser(c)
+		if user == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"success": false,
+				"error":   "User authentication required",
+			})
+		}
+		userID := user.ID.Hex()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		now := time.Now()
+		record := &models.IdempotencyRecord{
+			UserID:      userID,
+			Key:         key,
+			Fingerprint: requestFingerprint(c),
+			Status:      "processing",
+			CreatedAt:   now,
+			ExpiresAt:   now.Add(idempotencyTTL),
+		}
+
+		existing, err := repo.Reserve(ctx, record)
+		if err == nil && existing != nil && existing.Status == "processing" {
+			// Take over keys abandoned by a crashed request
+			if released, _ := repo.ReleaseStale(ctx, userID, key, now.Add(-idempotencyLockTimeout)); released {
+				existing, err = repo.Reserve(ctx, record)
+			}
+		}
+		if err != nil {
+			log.Printf("[IDEMPOTENCY] ERROR: %v", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"success": false,
+				"error":   "Failed to process Idempotency-Key",
+			})
+		}
+
+		if existing != nil {
+			if existing.Fingerprint != record.Fingerprint {
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+					"success": false,
+					"error":   "Idempotency-Key was already used for a different request",
+				})
+			}
+
+			if existing.Status != "completed" {
+				return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+					"success": false,
+					"error":   "A request with this Idempotency-Key is still being processed",
+				})
+			}
+
+			log.Printf("[IDEMPOTENCY] Replaying %s %s for key %s", c.Method(), c.Path(), key)
+			c.Set("Idempotent-Replayed", "true")
+			c.Set(fiber.HeaderContentType, existing.ContentType)
+			return c.Status(existing.StatusCode).Send(existing.Body)
+		}
+
+		handlerErr := c.Next()
+
+		storeCtx, storeCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer storeCancel()
+
+		// Server errors are not stored so the client can retry them
+		statusCode := c.Response().StatusCode()
+		if handlerErr != nil || statusCode >= fiber.StatusInternalServerError {
+			if err := repo.Release(storeCtx, userID, key); err != nil {
+				log.Printf("[IDEMPOTENCY] ERROR: %v", err)
+			}
+			return handlerErr
+		}
+
+		body := append([]byte(nil), c.Response().Body()...)
+		contentType := string(c.Response().Header.ContentType())
+		if err := repo.Complete(storeCtx, userID, key, statusCode, contentType, body); err != nil {
+			log.Printf("[IDEMPOTENCY] ERROR: %v", err)
+		}
+
+		return nil
+	}
+}
+
+// requestFingerprint identifies a request by method, path and body
+func requestFingerprint(c *fiber.Ctx) string {
+	hash := sha256.New()
+	hash.Write([]byte(c.Method()))
+	hash.Write([]byte{0})
+	hash.Write([]byte(c.Path()))
+	hash.Write([]byte{0})
+	hash.Write(c.Body())
+	return hex.EncodeToString(hash.Sum(nil))
+}
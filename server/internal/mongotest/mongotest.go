@@ -0,0 +1,64 @@
+// Package mongotest runs tests against a real MongoDB replica set.
+package mongotest
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// Setup points the config package at a new database on the replica set in
+// MONGODB_TEST_URI and drops it when the test ends. Transactions need a replica set.
+//
+// Without MONGODB_TEST_URI the test is skipped, except under CI where it fails so the
+// database tests cannot silently stop running. A configured server that cannot be
+// reached, or is not a replica set, always fails the test.
+func Setup(t testing.TB) {
+	t.Helper()
+
+	uri := os.Getenv("MONGODB_TEST_URI")
+	if uri == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal("MONGODB_TEST_URI must be set in CI")
+		}
+		t.Skip("MONGODB_TEST_URI is not set")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(options.Client().ApplyURI(uri))
+	if err != nil {
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	var hello struct {
+		SetName string `bson:"setName"`
+	}
+	if err := client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		client.Disconnect(ctx)
+		t.Fatalf("connecting to MongoDB: %v", err)
+	}
+	if hello.SetName == "" {
+		client.Disconnect(ctx)
+		t.Fatal("MONGODB_TEST_URI must point at a replica set")
+	}
+
+	previousClient, previousDB := config.MongoClient, config.MongoDB
+	config.MongoClient = client
+	config.MongoDB = client.Database(fmt.Sprintf("cinema_db_test_%d", time.Now().UnixNano()))
+
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		config.MongoDB.Drop(ctx)
+		client.Disconnect(ctx)
+		config.MongoClient, config.MongoDB = previousClient, previousDB
+	})
+}
@@ -5,24 +5,43 @@ import (
 	"github.com/tejas161/Cinema-Flix/internal/config"
 	"github.com/tejas161/Cinema-Flix/internal/handlers"
 	"github.com/tejas161/Cinema-Flix/internal/middleware"
+	"github.com/tejas161/Cinema-Flix/internal/services"
+	"github.com/tejas161/Cinema-Flix/internal/services/notifications"
+	"github.com/tejas161/Cinema-Flix/internal/services/payments"
+	"github.com/tejas161/Cinema-Flix/models"
 )
 
-func SetupRoutes(app *fiber.App) {
+func SetupRoutes(app *fiber.App, mailer *notifications.BookingMailer, pricer *services.DynamicPricer) {
 	// Initialize OAuth configuration
 	oauthConfig := config.NewOAuthConfig()
 
+	// Initialize session management
+	sessionConfig := config.NewSessionConfig()
+	sessionService := services.NewSessionService(sessionConfig)
+	loginStateService := services.NewLoginStateService(sessionConfig)
+	ticketService := services.NewTicketService(sessionConfig)
+	calendarFeedService := services.NewCalendarFeedService(sessionConfig)
+	requireAuth := middleware.RequireAuth(sessionService)
+	idempotent := middleware.Idempotency()
+
 	// Initialize handlers
 	moviesHandler := handlers.NewMoviesHandler()
 	theatersHandler := handlers.NewTheatersHandler()
 	showtimesHandler := handlers.NewShowtimesHandler()
-	bookingsHandler := handlers.NewBookingsHandler()
+	paymentProvider := payments.NewProviderFromEnv()
+	bookingsHandler := handlers.NewBookingsHandler(paymentProvider, ticketService, mailer, pricer)
+	paymentsHandler := handlers.NewPaymentsHandler(paymentProvider)
+	ticketsHandler := handlers.NewTicketsHandler(ticketService)
+	calendarHandler := handlers.NewCalendarHandler(calendarFeedService)
+	promotionsHandler := handlers.NewPromotionsHandler()
+	pricingRulesHandler := handlers.NewPricingRulesHandler()
 
 	// Public routes
 	app.Get("/health", handlers.HealthCheck)
-	app.Get("/auth/google/login", handlers.GoogleLogin(oauthConfig.GoogleConfig))
-	app.Get("/auth/google/callback", handlers.GoogleCallback(oauthConfig.GoogleConfig))
+	app.Get("/auth/google/login", handlers.GoogleLogin(oauthConfig.GoogleConfig, loginStateService))
+	app.Get("/auth/google/callback", handlers.GoogleCallback(oauthConfig.GoogleConfig, loginStateService, sessionService))
 	app.Get("/api/user/:id", handlers.GetUser())
-	app.Post("/auth/logout", handlers.Logout())
+	app.Post("/auth/logout", handlers.Logout(sessionService))
 
 	// Movie routes (public)
 	app.Get("/api/movies/test", moviesHandler.TestTMDBConnection())
@@ -35,20 +54,57 @@ func SetupRoutes(app *fiber.App) {
 	// Theater routes
 	app.Get("/api/theaters", theatersHandler.GetAllTheaters())
 	app.Get("/api/theaters/:id", theatersHandler.GetTheaterByID())
-	app.Post("/api/theaters", theatersHandler.CreateTheater())
+	app.Post("/api/theaters", requireAuth, middleware.RequireRole(models.RolePlatformAdmin), theatersHandler.CreateTheater())
+	app.Get("/api/theaters/:id/pricing-rule", pricingRulesHandler.GetTheaterPricingRule())
 
 	// Showtime routes
 	app.Get("/api/showtimes/:id", showtimesHandler.GetShowtimeByID())
-	app.Post("/api/showtimes", showtimesHandler.CreateShowtime())
-	app.Put("/api/showtimes/:id/seats", showtimesHandler.UpdateSeatStatus())
+	app.Get("/api/showtimes/:id/seatmap.svg", showtimesHandler.GetSeatMapSVG())
+	app.Post("/api/showtimes/:id/best-seats", showtimesHandler.GetBestSeats())
+	app.Post("/api/showtimes", requireAuth, middleware.RequireRole(models.RoleTheaterAdmin, models.RolePlatformAdmin), showtimesHandler.CreateShowtime())
+	app.Get("/api/showtimes/:id/price-changes", requireAuth, middleware.RequireRole(models.RoleTheaterStaff, models.RoleTheaterAdmin, models.RolePlatformAdmin), showtimesHandler.GetPriceChanges())
+	app.Put("/api/showtimes/:id/seats", requireAuth, middleware.RequireRole(models.RoleTheaterStaff, models.RoleTheaterAdmin, models.RolePlatformAdmin), showtimesHandler.UpdateSeatStatus())
 
 	// Booking routes (require authentication)
-	app.Post("/api/bookings", middleware.RequireAuth(), bookingsHandler.CreateBooking())
-	app.Get("/api/bookings/:id", middleware.RequireAuth(), bookingsHandler.GetBookingByID())
-	app.Get("/api/users/:userId/bookings", middleware.RequireAuth(), bookingsHandler.GetUserBookings())
-	app.Put("/api/bookings/:id/payment", middleware.RequireAuth(), bookingsHandler.ConfirmPayment())
-	app.Delete("/api/bookings/:id", middleware.RequireAuth(), bookingsHandler.CancelBooking())
+	app.Post("/api/bookings", requireAuth, idempotent, bookingsHandler.CreateBooking())
+	app.Get("/api/bookings/ref/:bookingRef", requireAuth, bookingsHandler.GetBookingByReference())
+	app.Get("/api/bookings/:id", requireAuth, bookingsHandler.GetBookingByID())
+	app.Get("/api/users/:userId/bookings", requireAuth, bookingsHandler.GetUserBookings())
+	app.Post("/api/bookings/:id/payment-intent", requireAuth, bookingsHandler.CreatePaymentIntent())
+	app.Put("/api/bookings/:id/payment", requireAuth, idempotent, bookingsHandler.ConfirmPayment())
+	app.Get("/api/bookings/:id/cancellation", requireAuth, bookingsHandler.GetCancellationQuote())
+	app.Delete("/api/bookings/:id", requireAuth, idempotent, bookingsHandler.CancelBooking())
+	app.Delete("/api/bookings/:id/seats", requireAuth, idempotent, bookingsHandler.CancelSeats())
+	app.Post("/api/bookings/:id/exchange", requireAuth, idempotent, bookingsHandler.ExchangeBooking())
+
+	// Ticket routes
+	app.Get("/api/bookings/:id/ticket.png", requireAuth, ticketsHandler.GetTicketQRCode())
+	app.Get("/api/bookings/:id/ticket.pdf", requireAuth, bookingsHandler.GetTicketPDF())
+	app.Post("/api/checkin", requireAuth, middleware.RequireRole(models.RoleTheaterStaff, models.RoleTheaterAdmin, models.RolePlatformAdmin), ticketsHandler.CheckIn())
+
+	// Calendar routes; the feed also accepts a signed token for calendar apps
+	app.Get("/api/bookings/:id/calendar.ics", requireAuth, calendarHandler.GetBookingCalendar())
+	app.Get("/api/users/me/calendar-feed", requireAuth, calendarHandler.GetCalendarFeedURL())
+	app.Post("/api/users/me/calendar-feed/reset", requireAuth, calendarHandler.ResetCalendarFeed())
+	app.Get("/api/users/me/bookings.ics", middleware.OptionalAuth(sessionService), calendarHandler.GetCalendarFeed())
+
+	// Promotion routes
+	app.Post("/api/promotions/validate", requireAuth, promotionsHandler.ValidatePromoCode())
+	app.Get("/api/promotions", requireAuth, middleware.RequireRole(models.RolePlatformAdmin), promotionsHandler.GetPromotions())
+	app.Post("/api/promotions", requireAuth, middleware.RequireRole(models.RolePlatformAdmin), promotionsHandler.CreatePromotion())
+	app.Put("/api/promotions/:id", requireAuth, middleware.RequireRole(models.RolePlatformAdmin), promotionsHandler.UpdatePromotion())
+
+	// Fee and tax rules
+	app.Get("/api/pricing-rules", requireAuth, middleware.RequireRole(models.RolePlatformAdmin), pricingRulesHandler.GetPricingRules())
+	app.Put("/api/pricing-rules", requireAuth, middleware.RequireRole(models.RoleTheaterAdmin, models.RolePlatformAdmin), pricingRulesHandler.SavePricingRule())
+	app.Delete("/api/pricing-rules/:id", requireAuth, middleware.RequireRole(models.RolePlatformAdmin), pricingRulesHandler.DeletePricingRule())
+
+	// Payment provider callbacks (authenticated by signature)
+	app.Post("/api/payments/webhook", paymentsHandler.Webhook())
 
 	// Protected routes (require authentication)
-	app.Get("/api/profile", middleware.RequireAuth(), handlers.GetProfile())
+	app.Get("/api/profile", requireAuth, handlers.GetProfile())
+
+	// Admin routes
+	app.Put("/api/users/:id/role", requireAuth, middleware.RequireRole(models.RolePlatformAdmin), handlers.UpdateUserRole())
 }
@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+)
+
+// iCalendar event statuses
+const (
+	EventConfirmed = "CONFIRMED"
+	EventTentative = "TENTATIVE"
+	EventCancelled = "CANCELLED"
+)
+
+const icsTimeLayout = "20060102T150405Z"
+
+// CalendarEvent is a single VEVENT in an iCalendar document
+type CalendarEvent struct {
+	UID         string
+	Sequence    int64 // Must grow whenever the event changes so clients pick up updates
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	Location    string
+	Latitude    float64
+	Longitude   float64
+	HasGeo      bool
+	Status      string
+	URL         string
+}
+
+// RenderCalendar renders events as an iCalendar (RFC 5545) document
+func RenderCalendar(name string, events []CalendarEvent) []byte {
+	var b strings.Builder
+	now := time.Now().UTC().Format(icsTimeLayout)
+
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//Cinema-Flix//Bookings//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:PUBLISH")
+	writeICSLine(&b, "X-WR-CALNAME:"+escapeICSText(name))
+	writeICSLine(&b, "REFRESH-INTERVAL;VALUE=DURATION:PT1H")
+	writeICSLine(&b, "X-PUBLISHED-TTL:PT1H")
+
+	for _, event := range events {
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+event.UID)
+		writeICSLine(&b, "DTSTAMP:"+now)
+		writeICSLine(&b, fmt.Sprintf("SEQUENCE:%d", event.Sequence))
+		writeICSLine(&b, "DTSTART:"+event.Start.UTC().Format(icsTimeLayout))
+		writeICSLine(&b, "DTEND:"+event.End.UTC().Format(icsTimeLayout))
+		writeICSLine(&b, "SUMMARY:"+escapeICSText(event.Summary))
+		if event.Description != "" {
+			writeICSLine(&b, "DESCRIPTION:"+escapeICSText(event.Description))
+		}
+		if event.Location != "" {
+			writeICSLine(&b, "LOCATION:"+escapeICSText(event.Location))
+		}
+		if event.HasGeo {
+			writeICSLine(&b, fmt.Sprintf("GEO:%.6f;%.6f", event.Latitude, event.Longitude))
+		}
+		if event.URL != "" {
+			writeICSLine(&b, "URL:"+event.URL)
+		}
+		writeICSLine(&b, "STATUS:"+event.Status)
+		if event.Status == EventCancelled {
+			writeICSLine(&b, "TRANSP:TRANSPARENT")
+		} else {
+			writeICSLine(&b, "TRANSP:OPAQUE")
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// escapeICSText escapes a TEXT value
+func escapeICSText(value string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(value)
+}
+
+// writeICSLine writes a content line folded at 75 octets, without splitting UTF-8 characters
+func writeICSLine(b *strings.Builder, line string) {
+	limit := 75
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		limit = 74 // Continuation lines start with a space
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// CalendarFeedService issues the tokens that let calendar apps fetch a user's
+// booking feed without a session cookie
+type CalendarFeedService struct {
+	signer *TokenSigner
+	users  *db.UserRepository
+}
+
+// calendarFeedClaims is the data embedded in a feed token
+type calendarFeedClaims struct {
+	UserID  string `json:"uid"`
+	Version int    `json:"v,omitempty"` // The user's calendar feed version when the token was issued
+}
+
+// NewCalendarFeedService creates a new calendar feed service
+func NewCalendarFeedService(cfg *config.SessionConfig) *CalendarFeedService {
+	return &CalendarFeedService{
+		signer: NewTokenSigner(cfg.Secret, "calendar-feed"),
+		users:  db.NewUserRepository(),
+	}
+}
+
+// FeedToken returns the feed token for a user. Feed tokens do not expire;
+// they stay valid until the user resets their feed.
+func (s *CalendarFeedService) FeedToken(user *models.User) (string, error) {
+	return s.signer.Sign(calendarFeedClaims{UserID: user.ID.Hex(), Version: user.CalendarFeedVersion}, time.Time{})
+}
+
+// VerifyFeedToken returns the user a feed token was issued for,
+// rejecting tokens issued before the user last reset their feed
+func (s *CalendarFeedService) VerifyFeedToken(ctx context.Context, token string) (*models.User, error) {
+	var claims calendarFeedClaims
+	if err := s.signer.Verify(token, &claims); err != nil {
+		return nil, err
+	}
+
+	user, err := s.users.FindUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil || user.CalendarFeedVersion != claims.Version {
+		return nil, ErrInvalidToken
+	}
+	return user, nil
+}
+
+// ResetFeedToken revokes the user's feed tokens and returns a new one
+func (s *CalendarFeedService) ResetFeedToken(ctx context.Context, user *models.User) (string, error) {
+	updated, err := s.users.ResetCalendarFeed(ctx, user.ID)
+	if err != nil {
+		return "", err
+	}
+	if updated == nil {
+		return "", fmt.Errorf("user not found")
+	}
+	return s.FeedToken(updated)
+}
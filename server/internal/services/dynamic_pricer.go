@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+const dynamicPricingInterval = 10 * time.Minute
+
+// DynamicPricer recomputes seat prices of showtimes at theaters with dynamic pricing enabled
+type DynamicPricer struct {
+	theaters     *db.TheaterRepository
+	showtimes    *db.ShowtimeRepository
+	pricingRules *db.PricingRuleRepository
+	priceChanges *db.PriceChangeRepository
+	interval     time.Duration
+}
+
+// NewDynamicPricer creates a new dynamic pricer
+func NewDynamicPricer() *DynamicPricer {
+	return &DynamicPricer{
+		theaters:     db.NewTheaterRepository(),
+		showtimes:    db.NewShowtimeRepository(),
+		pricingRules: db.NewPricingRuleRepository(),
+		priceChanges: db.NewPriceChangeRepository(),
+		interval:     dynamicPricingInterval,
+	}
+}
+
+// Start reprices upcoming showtimes on a schedule until the context is cancelled
+func (p *DynamicPricer) Start(ctx context.Context) {
+	log.Printf("[PRICING] Dynamic pricer started (interval %s)", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[PRICING] Dynamic pricer stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce reprices every upcoming showtime at theaters with dynamic pricing enabled
+func (p *DynamicPricer) RunOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, p.interval)
+	defer cancel()
+
+	theaters, err := p.theaters.FindDynamicPricingTheaters(ctx)
+	if err != nil {
+		log.Printf("[PRICING] ERROR: %v", err)
+		return
+	}
+
+	changed := 0
+	for _, theater := range theaters {
+		showtimeIDs, err := p.showtimes.FindUpcomingShowtimeIDs(ctx, theater.ID, time.Now())
+		if err != nil {
+			log.Printf("[PRICING] ERROR: theater %s: %v", theater.ID.Hex(), err)
+			continue
+		}
+
+		for _, showtimeID := range showtimeIDs {
+			change, err := p.Reprice(ctx, showtimeID, models.RepriceScheduled)
+			if err != nil {
+				log.Printf("[PRICING] ERROR: showtime %s: %v", showtimeID.Hex(), err)
+				continue
+			}
+			if change != nil {
+				changed++
+			}
+		}
+	}
+
+	if changed > 0 {
+		log.Printf("[PRICING] Repriced %d showtimes", changed)
+	}
+}
+
+// Reprice recomputes the seat prices of a showtime and logs the change.
+// It returns nil when the theater does not use dynamic pricing or no price changed.
+// The prices are read and written in one transaction, so a seat held meanwhile is
+// never repriced after the customer saw its price.
+func (p *DynamicPricer) Reprice(ctx context.Context, showtimeID bson.ObjectID, reason string) (*models.PriceChange, error) {
+	session, err := config.MongoClient.StartSession()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start session: %w", err)
+	}
+	defer session.EndSession(ctx)
+
+	var change *models.PriceChange
+	_, err = session.WithTransaction(ctx, func(sc context.Context) (interface{}, error) {
+		change = nil
+
+		showtime, err := p.showtimes.FindShowtimeByID(sc, showtimeID)
+		if err != nil || showtime == nil {
+			return nil, err
+		}
+		now := time.Now()
+		if !showtime.IsAvailable() || !showtime.ShowTime.After(now) {
+			return nil, nil
+		}
+
+		theater, err := p.theaters.FindTheaterByID(sc, showtime.TheaterID)
+		if err != nil || theater == nil || theater.DynamicPricing == nil || !theater.DynamicPricing.Enabled {
+			return nil, err
+		}
+
+		pending := priceChange(showtime, theater, reason, now)
+		if pending == nil {
+			return nil, nil
+		}
+
+		rule, err := p.pricingRules.RuleFor(sc, theater.ID, theater.State)
+		if err != nil {
+			return nil, err
+		}
+		pricing := rule.ShowPricingForSeats(repricedSeats(showtime.Seats, pending))
+
+		if err := p.showtimes.ApplyPriceChange(sc, pending, pricing); err != nil {
+			return nil, err
+		}
+		if err := p.priceChanges.RecordPriceChange(sc, pending); err != nil {
+			return nil, err
+		}
+
+		change = pending
+		return nil, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return change, nil
+}
+
+// priceChange works out the new prices of a showtime's available seats.
+// It returns nil when neither the multiplier nor any price changes.
+func priceChange(showtime *models.Showtime, theater *models.Theater, reason string, now time.Time) *models.PriceChange {
+	multiplier, factors := theater.DynamicPricing.Multiplier(showtime, theater, now)
+
+	type priceKey struct {
+		base     float64
+		previous float64
+	}
+	groups := make(map[priceKey]*models.SeatPriceChange)
+	for i := range showtime.Seats {
+		seat := &showtime.Seats[i]
+		if seat.Status != models.SeatAvailable {
+			continue
+		}
+
+		base := seat.SeatBasePrice()
+		price := models.DynamicSeatPrice(base, multiplier)
+		if price == seat.Price && seat.BasePrice > 0 {
+			continue
+		}
+
+		key := priceKey{base: base, previous: seat.Price}
+		group, ok := groups[key]
+		if !ok {
+			group = &models.SeatPriceChange{BasePrice: base, PreviousPrice: seat.Price, Price: price}
+			groups[key] = group
+		}
+		group.SeatIDs = append(group.SeatIDs, seat.SeatID)
+	}
+
+	// Showtimes never repriced sell at their base prices
+	previous := showtime.PriceMultiplier
+	if previous == 0 {
+		previous = 1
+	}
+	if len(groups) == 0 && multiplier == previous {
+		return nil
+	}
+
+	prices := make([]models.SeatPriceChange, 0, len(groups))
+	for _, group := range groups {
+		prices = append(prices, *group)
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].BasePrice < prices[j].BasePrice })
+
+	return &models.PriceChange{
+		ID:                 bson.NewObjectID(),
+		ShowtimeID:         showtime.ID,
+		TheaterID:          theater.ID,
+		Reason:             reason,
+		PreviousMultiplier: previous,
+		Multiplier:         multiplier,
+		Factors:            factors,
+		Config:             *theater.DynamicPricing,
+		Prices:             prices,
+		CreatedAt:          now,
+	}
+}
+
+// repricedSeats returns the seats as they will be once the change is applied
+func repricedSeats(seats []models.Seat, change *models.PriceChange) []models.Seat {
+	prices := make(map[string]float64)
+	for _, price := range change.Prices {
+		for _, seatID := range price.SeatIDs {
+			prices[seatID] = price.Price
+		}
+	}
+
+	repriced := make([]models.Seat, len(seats))
+	copy(repriced, seats)
+	for i := range repriced {
+		if price, ok := prices[repriced[i].SeatID]; ok {
+			repriced[i].Price = price
+		}
+	}
+	return repriced
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/services/notifications"
+	"github.com/tejas161/Cinema-Flix/models"
+)
+
+const (
+	holdReaperInterval = 30 * time.Second
+	holdReaperBatch    = 200
+
+	// holdGracePeriod keeps the reaper away from holds that only just lapsed,
+	// so a payment confirmed right at the deadline can still convert its seats
+	holdGracePeriod = time.Minute
+)
+
+// HoldReaper periodically expires unpaid bookings and releases lapsed seat holds
+type HoldReaper struct {
+	bookings   *db.BookingRepository
+	showtimes  *db.ShowtimeRepository
+	promotions *db.PromotionRepository
+	theaters   *db.TheaterRepository
+	mailer     *notifications.BookingMailer
+	interval   time.Duration
+}
+
+// NewHoldReaper creates a new hold reaper
+func NewHoldReaper(mailer *notifications.BookingMailer) *HoldReaper {
+	return &HoldReaper{
+		bookings:   db.NewBookingRepository(),
+		showtimes:  db.NewShowtimeRepository(),
+		promotions: db.NewPromotionRepository(),
+		theaters:   db.NewTheaterRepository(),
+		mailer:     mailer,
+		interval:   holdReaperInterval,
+	}
+}
+
+// Start runs the reaper until the context is cancelled
+func (r *HoldReaper) Start(ctx context.Context) {
+	log.Printf("[HOLDS] Hold reaper started (interval %s)", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		r.RunOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Printf("[HOLDS] Hold reaper stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce performs a single reaping pass
+func (r *HoldReaper) RunOnce(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, r.interval)
+	defer cancel()
+
+	now := time.Now()
+	expired := r.expireUnpaidBookings(ctx, now)
+	released := r.releaseLapsedHolds(ctx, now.Add(-holdGracePeriod))
+
+	if expired > 0 || released > 0 {
+		log.Printf("[HOLDS] Expired %d unpaid bookings, released %d lapsed seat holds", expired, released)
+	}
+}
+
+// expireUnpaidBookings marks unpaid bookings past their deadline as expired and frees their seats
+func (r *HoldReaper) expireUnpaidBookings(ctx context.Context, now time.Time) int {
+	bookings, err := r.bookings.FindExpiredPendingBookings(ctx, now, holdReaperBatch)
+	if err != nil {
+		log.Printf("[HOLDS] ERROR: %v", err)
+		return 0
+	}
+
+	expired := 0
+	for _, booking := range bookings {
+		ok, err := r.bookings.MarkBookingExpired(ctx, booking.ID)
+		if err != nil {
+			log.Printf("[HOLDS] ERROR: booking %s: %v", booking.BookingID, err)
+			continue
+		}
+		if !ok {
+			continue // Paid or cancelled while we were looking
+		}
+		expired++
+
+		for _, seat := range booking.Seats {
+			if _, err := r.showtimes.ReleaseHeldSeat(ctx, booking.ShowtimeID, seat.SeatID, booking.BookingID); err != nil {
+				log.Printf("[HOLDS] ERROR: releasing seat %s of booking %s: %v", seat.SeatID, booking.BookingID, err)
+			}
+		}
+
+		if booking.Promotion != nil {
+			if err := r.promotions.Release(ctx, booking.Promotion.PromotionID); err != nil {
+				log.Printf("[HOLDS] ERROR: booking %s: %v", booking.BookingID, err)
+			}
+		}
+
+		// The theater gives the email its name and time zone
+		details := notifications.BookingDetails{Booking: &booking}
+		if theater, err := r.theaters.FindTheaterByID(ctx, booking.TheaterID); err == nil {
+			details.Theater = theater
+		}
+		r.mailer.BookingExpired(details)
+	}
+
+	return expired
+}
+
+// releaseLapsedHolds releases held or blocked seats whose hold expired before the cutoff.
+// This catches staff blocks and holds left behind by bookings that no longer exist.
+func (r *HoldReaper) releaseLapsedHolds(ctx context.Context, cutoff time.Time) int {
+	showtimes, err := r.showtimes.FindShowtimesWithExpiredHolds(ctx, cutoff)
+	if err != nil {
+		log.Printf("[HOLDS] ERROR: %v", err)
+		return 0
+	}
+
+	released := 0
+	for _, showtime := range showtimes {
+		for _, seat := range showtime.Seats {
+			if seat.HoldExpiresAt == nil || !seat.HoldExpiresAt.Before(cutoff) {
+				continue
+			}
+			if seat.Status != models.SeatHeld && seat.Status != models.SeatBlocked {
+				continue
+			}
+
+			// A hold belonging to a paid booking is confirmed rather than released
+			if seat.Status == models.SeatHeld && seat.HeldFor != "" {
+				booking, err := r.bookings.FindBookingByBookingID(ctx, seat.HeldFor)
+				if err != nil {
+					log.Printf("[HOLDS] ERROR: %v", err)
+					continue
+				}
+				if booking != nil && booking.PaymentStatus == "completed" && booking.BookingStatus == "confirmed" {
+					if err := r.showtimes.ConfirmHeldSeats(ctx, showtime.ID, []string{seat.SeatID}, seat.HeldFor); err != nil {
+						log.Printf("[HOLDS] ERROR: %v", err)
+					}
+					continue
+				}
+			}
+
+			ok, err := r.showtimes.ReleaseExpiredHold(ctx, showtime.ID, seat, cutoff)
+			if err != nil {
+				log.Printf("[HOLDS] ERROR: releasing seat %s: %v", seat.SeatID, err)
+				continue
+			}
+			if ok {
+				released++
+			}
+		}
+	}
+
+	return released
+}
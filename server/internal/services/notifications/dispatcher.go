@@ -0,0 +1,97 @@
+package notifications
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	dispatcherQueueSize = 256
+	dispatcherWorkers   = 2
+	sendTimeout         = 30 * time.Second
+	maxSendAttempts     = 5
+	firstRetryDelay     = 10 * time.Second
+)
+
+// delivery is a queued message and the number of attempts made so far
+type delivery struct {
+	msg      Message
+	attempts int
+}
+
+// Dispatcher sends messages in the background so that request handlers never
+// wait on the mail server. Failed sends are retried with exponential backoff.
+// Queued messages live in memory only and are lost on restart.
+type Dispatcher struct {
+	notifier Notifier
+	queue    chan delivery
+}
+
+// NewDispatcher creates a new dispatcher for the notifier
+func NewDispatcher(notifier Notifier) *Dispatcher {
+	return &Dispatcher{
+		notifier: notifier,
+		queue:    make(chan delivery, dispatcherQueueSize),
+	}
+}
+
+// Enqueue schedules a message for delivery without blocking
+func (d *Dispatcher) Enqueue(msg Message) {
+	d.enqueue(delivery{msg: msg})
+}
+
+func (d *Dispatcher) enqueue(item delivery) {
+	select {
+	case d.queue <- item:
+	default:
+		log.Printf("[MAIL] ERROR: Queue full, dropping %q to %s", item.msg.Subject, item.msg.To)
+	}
+}
+
+// Start runs the delivery workers until the context is cancelled
+func (d *Dispatcher) Start(ctx context.Context) {
+	log.Printf("[MAIL] Mail dispatcher started (%d workers)", dispatcherWorkers)
+
+	for i := 0; i < dispatcherWorkers; i++ {
+		go d.work(ctx)
+	}
+
+	<-ctx.Done()
+	log.Printf("[MAIL] Mail dispatcher stopped")
+}
+
+func (d *Dispatcher) work(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case item := <-d.queue:
+			d.deliver(ctx, item)
+		}
+	}
+}
+
+// deliver sends one message, scheduling a retry when it fails
+func (d *Dispatcher) deliver(ctx context.Context, item delivery) {
+	sendCtx, cancel := context.WithTimeout(ctx, sendTimeout)
+	err := d.notifier.Send(sendCtx, item.msg)
+	cancel()
+	if err == nil {
+		return
+	}
+
+	item.attempts++
+	if item.attempts >= maxSendAttempts {
+		log.Printf("[MAIL] ERROR: Giving up on %q to %s after %d attempts: %v", item.msg.Subject, item.msg.To, item.attempts, err)
+		return
+	}
+
+	delay := firstRetryDelay << (item.attempts - 1)
+	log.Printf("[MAIL] Sending %q to %s failed (attempt %d), retrying in %s: %v", item.msg.Subject, item.msg.To, item.attempts, delay, err)
+	time.AfterFunc(delay, func() {
+		if ctx.Err() == nil {
+			d.enqueue(item)
+		}
+	})
+}
@@ -0,0 +1,193 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"log"
+	"net/mail"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+)
+
+//go:embed templates/*
+var templateFS embed.FS
+
+// Booking email kinds; each has a .html and a .txt template
+const (
+	EmailBookingCreated   = "booking_created"
+	EmailPaymentConfirmed = "payment_confirmed"
+	EmailBookingCancelled = "booking_cancelled"
+	EmailBookingExpired   = "booking_expired"
+)
+
+const (
+	emailTimeLayout   = "Mon, 02 Jan 2006 03:04 PM"
+	emailAmountFormat = "$%.2f"
+)
+
+var emailSubjects = map[string]string{
+	EmailBookingCreated:   "Complete your payment for booking %s",
+	EmailPaymentConfirmed: "Your booking %s is confirmed",
+	EmailBookingCancelled: "Your booking %s was cancelled",
+	EmailBookingExpired:   "Your booking %s has expired",
+}
+
+// BookingDetails is the booking context an email is rendered from.
+// MovieTitle and Theater are optional.
+type BookingDetails struct {
+	Booking    *models.Booking
+	MovieTitle string
+	Theater    *models.Theater
+}
+
+// emailData is the data passed to the templates
+type emailData struct {
+	Name        string
+	BookingID   string
+	MovieTitle  string
+	TheaterName string
+	ShowTime    string
+	Seats       string
+	Total       string
+	ExpiresAt   string
+	Refund      string
+	ClientURL   string
+}
+
+type emailTemplate struct {
+	html *htmltemplate.Template
+	text *texttemplate.Template
+}
+
+// BookingMailer renders booking emails and queues them for delivery
+type BookingMailer struct {
+	dispatcher *Dispatcher
+	clientURL  string
+	templates  map[string]emailTemplate
+}
+
+// NewBookingMailer creates a booking mailer using the configured notifier
+func NewBookingMailer(cfg *config.MailConfig) *BookingMailer {
+	templates := make(map[string]emailTemplate, len(emailSubjects))
+	for kind := range emailSubjects {
+		templates[kind] = emailTemplate{
+			html: htmltemplate.Must(htmltemplate.ParseFS(templateFS, "templates/layout.html", "templates/"+kind+".html")),
+			text: texttemplate.Must(texttemplate.ParseFS(templateFS, "templates/"+kind+".txt", "templates/details.txt")),
+		}
+	}
+
+	return &BookingMailer{
+		dispatcher: NewDispatcher(NewNotifier(cfg)),
+		clientURL:  cfg.ClientURL,
+		templates:  templates,
+	}
+}
+
+// Start runs the delivery workers until the context is cancelled
+func (m *BookingMailer) Start(ctx context.Context) {
+	m.dispatcher.Start(ctx)
+}
+
+// BookingCreated asks the user to complete payment before the hold expires
+func (m *BookingMailer) BookingCreated(details BookingDetails) {
+	m.send(EmailBookingCreated, details, "", nil)
+}
+
+// PaymentConfirmed confirms a paid booking, attaching the PDF ticket when given
+func (m *BookingMailer) PaymentConfirmed(details BookingDetails, ticketPDF []byte) {
+	var attachments []Attachment
+	if len(ticketPDF) > 0 {
+		attachments = append(attachments, Attachment{
+			Filename:    "ticket-" + details.Booking.BookingID + ".pdf",
+			ContentType: "application/pdf",
+			Data:        ticketPDF,
+		})
+	}
+	m.send(EmailPaymentConfirmed, details, "", attachments)
+}
+
+// BookingCancelled tells the user about a cancellation and its refund, if any
+func (m *BookingMailer) BookingCancelled(details BookingDetails, refund *models.Refund) {
+	amount := ""
+	if refund != nil && refund.Amount > 0 {
+		amount = fmt.Sprintf(emailAmountFormat, refund.Amount)
+	}
+	m.send(EmailBookingCancelled, details, amount, nil)
+}
+
+// BookingExpired tells the user their unpaid booking was released
+func (m *BookingMailer) BookingExpired(details BookingDetails) {
+	m.send(EmailBookingExpired, details, "", nil)
+}
+
+// send renders an email and queues it
+func (m *BookingMailer) send(kind string, details BookingDetails, refund string, attachments []Attachment) {
+	booking := details.Booking
+	if booking == nil || booking.UserEmail == "" {
+		return
+	}
+
+	msg, err := m.render(kind, details, refund)
+	if err != nil {
+		log.Printf("[MAIL] ERROR: Failed to render %s for booking %s: %v", kind, booking.BookingID, err)
+		return
+	}
+	msg.Attachments = attachments
+
+	m.dispatcher.Enqueue(msg)
+}
+
+// render builds the message for a booking email
+func (m *BookingMailer) render(kind string, details BookingDetails, refund string) (Message, error) {
+	booking := details.Booking
+	tmpl, ok := m.templates[kind]
+	if !ok {
+		return Message{}, fmt.Errorf("unknown email %q", kind)
+	}
+
+	seats := make([]string, 0, len(booking.Seats))
+	for _, seat := range booking.Seats {
+		seats = append(seats, seat.SeatID)
+	}
+
+	data := emailData{
+		Name:       booking.UserName,
+		BookingID:  booking.BookingID,
+		MovieTitle: details.MovieTitle,
+		ShowTime:   details.Theater.LocalTime(booking.ShowTime).Format(emailTimeLayout),
+		Seats:      strings.Join(seats, ", "),
+		Total:      fmt.Sprintf(emailAmountFormat, booking.Pricing.TotalAmount),
+		ExpiresAt:  details.Theater.LocalTime(booking.ExpiresAt).Format(emailTimeLayout),
+		Refund:     refund,
+		ClientURL:  m.clientURL,
+	}
+	if data.Name == "" {
+		data.Name = "there"
+	}
+	if details.Theater != nil {
+		data.TheaterName = details.Theater.Name
+	}
+
+	var html, text bytes.Buffer
+	if err := tmpl.html.ExecuteTemplate(&html, "layout", data); err != nil {
+		return Message{}, err
+	}
+	if err := tmpl.text.ExecuteTemplate(&text, kind+".txt", data); err != nil {
+		return Message{}, err
+	}
+
+	to := (&mail.Address{Name: booking.UserName, Address: booking.UserEmail}).String()
+
+	return Message{
+		To:      to,
+		Subject: fmt.Sprintf(emailSubjects[kind], booking.BookingID),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,48 @@
+package notifications
+
+import (
+	"context"
+	"log"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+)
+
+// Notifier delivers a message to its recipient
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message is an email with a plain text and an HTML body
+type Message struct {
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent along with a message
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// NewNotifier creates the SMTP notifier, or a logging notifier when SMTP is not configured
+func NewNotifier(cfg *config.MailConfig) Notifier {
+	if cfg.Host == "" {
+		log.Printf("[MAIL] SMTP_HOST not set, emails will only be logged")
+		return LogNotifier{}
+	}
+	log.Printf("[MAIL] Sending email through %s:%d", cfg.Host, cfg.Port)
+	return NewSMTPNotifier(cfg)
+}
+
+// LogNotifier writes messages to the log instead of sending them
+type LogNotifier struct{}
+
+// Send logs the message
+func (LogNotifier) Send(ctx context.Context, msg Message) error {
+	log.Printf("[MAIL] (not sent) To: %s Subject: %q Attachments: %d", msg.To, msg.Subject, len(msg.Attachments))
+	return nil
+}
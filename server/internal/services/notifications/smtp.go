@@ -0,0 +1,160 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"time"
+
+	"github.com/tejas161/Cinema-Flix/internal/config"
+)
+
+// SMTPNotifier sends messages through an SMTP server.
+// STARTTLS is used whenever the server offers it; authentication is only
+// attempted when a username is configured, so local SMTP sinks work as-is.
+type SMTPNotifier struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     *mail.Address
+}
+
+// NewSMTPNotifier creates a new SMTP notifier
+func NewSMTPNotifier(cfg *config.MailConfig) *SMTPNotifier {
+	from, err := mail.ParseAddress(cfg.From)
+	if err != nil {
+		from = &mail.Address{Address: cfg.From}
+	}
+
+	return &SMTPNotifier{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		host:     cfg.Host,
+		username: cfg.Username,
+		password: cfg.Password,
+		from:     from,
+	}
+}
+
+// Send delivers the message
+func (n *SMTPNotifier) Send(ctx context.Context, msg Message) error {
+	to, err := mail.ParseAddress(msg.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", msg.To, err)
+	}
+
+	body, err := n.buildMessage(to, msg)
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if n.username != "" {
+		auth = smtp.PlainAuth("", n.username, n.password, n.host)
+	}
+
+	// net/smtp has no context support, so honour cancellation before dialling
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := smtp.SendMail(n.addr, auth, n.from.Address, []string{to.Address}, body); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", to.Address, err)
+	}
+	return nil
+}
+
+// buildMessage encodes the message as multipart MIME
+func (n *SMTPNotifier) buildMessage(to *mail.Address, msg Message) ([]byte, error) {
+	var buf bytes.Buffer
+
+	mixed := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "From: %s\r\n", n.from.String())
+	fmt.Fprintf(&buf, "To: %s\r\n", to.String())
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&buf, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%s\r\n\r\n", mixed.Boundary())
+
+	// Text and HTML alternatives, assembled first so their boundary is known
+	var altBuf bytes.Buffer
+	alternative := multipart.NewWriter(&altBuf)
+	for _, body := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", msg.Text},
+		{"text/html; charset=UTF-8", msg.HTML},
+	} {
+		if body.content == "" {
+			continue
+		}
+		part, err := alternative.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {body.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(part)
+		if _, err := io.WriteString(qp, body.content); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := alternative.Close(); err != nil {
+		return nil, err
+	}
+
+	altPart, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + alternative.Boundary()},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := altPart.Write(altBuf.Bytes()); err != nil {
+		return nil, err
+	}
+
+	// Attachments
+	for _, attachment := range msg.Attachments {
+		part, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := writeBase64Lines(part, attachment.Data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64Lines writes data as base64 wrapped at 76 characters per line
+func writeBase64Lines(w io.Writer, data []byte) error {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 0 {
+		n := min(76, len(encoded))
+		if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+			return err
+		}
+		encoded = encoded[n:]
+	}
+	return nil
+}
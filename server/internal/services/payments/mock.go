@@ -0,0 +1,233 @@
+package payments
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// webhookTolerance bounds how old a signed webhook may be
+const webhookTolerance = 5 * time.Minute
+
+// MockProvider is an in-memory payment provider for development and tests.
+// Intents are authorized immediately and can be captured and refunded.
+// Setting the metadata key "simulate" to "decline" makes capture fail.
+type MockProvider struct {
+	mu            sync.Mutex
+	webhookSecret []byte
+	intents       map[string]*Intent
+	metadata      map[string]map[string]string
+	refunded      map[string]int64
+	idempotent    map[string]string
+}
+
+// NewMockProvider creates a new mock provider.
+// Webhooks are rejected when webhookSecret is empty.
+func NewMockProvider(webhookSecret string) *MockProvider {
+	return &MockProvider{
+		webhookSecret: []byte(webhookSecret),
+		intents:       make(map[string]*Intent),
+		metadata:      make(map[string]map[string]string),
+		refunded:      make(map[string]int64),
+		idempotent:    make(map[string]string),
+	}
+}
+
+// Name identifies the mock provider
+func (p *MockProvider) Name() string {
+	return "mock"
+}
+
+// CreateIntent creates an authorized intent
+func (p *MockProvider) CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error) {
+	if req.Amount <= 0 {
+		return nil, fmt.Errorf("intent amount must be positive")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if req.IdempotencyKey != "" {
+		if id, ok := p.idempotent["intent:"+req.IdempotencyKey]; ok {
+			intent := *p.intents[id]
+			return &intent, nil
+		}
+	}
+
+	intent := &Intent{
+		ID:            "pi_mock_" + bson.NewObjectID().Hex(),
+		Amount:        req.Amount,
+		Currency:      req.Currency,
+		Status:        IntentRequiresCapture,
+		Reference:     req.Reference,
+		PaymentMethod: "card",
+		CreatedAt:     time.Now(),
+	}
+	p.intents[intent.ID] = intent
+	p.metadata[intent.ID] = req.Metadata
+	if req.IdempotencyKey != "" {
+		p.idempotent["intent:"+req.IdempotencyKey] = intent.ID
+	}
+
+	result := *intent
+	return &result, nil
+}
+
+// GetIntent returns a copy of an intent
+func (p *MockProvider) GetIntent(ctx context.Context, intentID string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	result := *intent
+	return &result, nil
+}
+
+// Capture captures the full authorized amount
+func (p *MockProvider) Capture(ctx context.Context, intentID string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	switch intent.Status {
+	case IntentSucceeded:
+		// Capturing twice is a no-op
+	case IntentRequiresCapture:
+		if p.metadata[intentID]["simulate"] == "decline" {
+			intent.Status = IntentFailed
+			return nil, ErrPaymentFailed
+		}
+		intent.Status = IntentSucceeded
+		intent.CapturedAmount = intent.Amount
+	default:
+		return nil, ErrPaymentFailed
+	}
+
+	result := *intent
+	return &result, nil
+}
+
+// Cancel voids an authorized intent so it can no longer be captured
+func (p *MockProvider) Cancel(ctx context.Context, intentID string) (*Intent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	switch intent.Status {
+	case IntentSucceeded:
+		return nil, ErrIntentCaptured
+	case IntentRequiresCapture:
+		intent.Status = IntentCanceled
+	default:
+		// Failed and canceled intents cannot be captured anyway
+	}
+
+	result := *intent
+	return &result, nil
+}
+
+// Refund refunds part or all of a captured intent
+func (p *MockProvider) Refund(ctx context.Context, req RefundRequest) (*Refund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[req.IntentID]
+	if !ok {
+		return nil, ErrIntentNotFound
+	}
+
+	if req.IdempotencyKey != "" {
+		if id, ok := p.idempotent["refund:"+req.IdempotencyKey]; ok {
+			return &Refund{ID: id, IntentID: req.IntentID, Amount: req.Amount, Status: "succeeded", CreatedAt: time.Now()}, nil
+		}
+	}
+
+	if req.Amount <= 0 || p.refunded[req.IntentID]+req.Amount > intent.CapturedAmount {
+		return nil, ErrInvalidRefund
+	}
+	p.refunded[req.IntentID] += req.Amount
+
+	refund := &Refund{
+		ID:        "re_mock_" + bson.NewObjectID().Hex(),
+		IntentID:  req.IntentID,
+		Amount:    req.Amount,
+		Status:    "succeeded",
+		CreatedAt: time.Now(),
+	}
+	if req.IdempotencyKey != "" {
+		p.idempotent["refund:"+req.IdempotencyKey] = refund.ID
+	}
+
+	return refund, nil
+}
+
+// VerifyWebhook checks a "t=<unix>,v1=<hex hmac>" signature over "<t>.<payload>"
+func (p *MockProvider) VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error) {
+	var timestamp, provided string
+	for _, part := range strings.Split(signature, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			provided = value
+		}
+	}
+
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || provided == "" || len(p.webhookSecret) == 0 {
+		return nil, ErrBadSignature
+	}
+
+	age := time.Since(time.Unix(unix, 0))
+	if age > webhookTolerance || age < -webhookTolerance {
+		return nil, ErrBadSignature
+	}
+
+	expected := p.sign(timestamp, payload)
+	if !hmac.Equal([]byte(expected), []byte(provided)) {
+		return nil, ErrBadSignature
+	}
+
+	var event WebhookEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" || event.Type == "" {
+		return nil, fmt.Errorf("invalid webhook payload")
+	}
+
+	return &event, nil
+}
+
+// SignWebhook produces the signature header value for a payload, for local testing of webhooks
+func (p *MockProvider) SignWebhook(payload []byte, at time.Time) string {
+	timestamp := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + timestamp + ",v1=" + p.sign(timestamp, payload)
+}
+
+func (p *MockProvider) sign(timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, p.webhookSecret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,134 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMockCaptureAndRefundLimits(t *testing.T) {
+	ctx := context.Background()
+	p := NewMockProvider("secret")
+
+	if _, err := p.CreateIntent(ctx, IntentRequest{Amount: 0, Currency: "usd"}); err == nil {
+		t.Fatal("created an intent for nothing")
+	}
+
+	intent, err := p.CreateIntent(ctx, IntentRequest{Amount: 1000, Currency: "usd", Reference: "BK1", IdempotencyKey: "booking:1"})
+	if err != nil {
+		t.Fatalf("creating intent: %v", err)
+	}
+	if intent.Status != IntentRequiresCapture || intent.CapturedAmount != 0 {
+		t.Fatalf("new intent %s with %d captured, want requires_capture with nothing", intent.Status, intent.CapturedAmount)
+	}
+	if retried, _ := p.CreateIntent(ctx, IntentRequest{Amount: 1000, Currency: "usd", IdempotencyKey: "booking:1"}); retried.ID != intent.ID {
+		t.Fatalf("retry created intent %s, want %s", retried.ID, intent.ID)
+	}
+
+	// Nothing can be refunded before the capture
+	if _, err := p.Refund(ctx, RefundRequest{IntentID: intent.ID, Amount: 100}); !errors.Is(err, ErrInvalidRefund) {
+		t.Fatalf("refund before capture: %v, want ErrInvalidRefund", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		captured, err := p.Capture(ctx, intent.ID)
+		if err != nil {
+			t.Fatalf("capture %d: %v", i+1, err)
+		}
+		if captured.Status != IntentSucceeded || captured.CapturedAmount != 1000 {
+			t.Fatalf("capture %d: %s with %d captured, want succeeded with 1000", i+1, captured.Status, captured.CapturedAmount)
+		}
+	}
+
+	first, err := p.Refund(ctx, RefundRequest{IntentID: intent.ID, Amount: 600, IdempotencyKey: "refund:1"})
+	if err != nil {
+		t.Fatalf("refunding 600: %v", err)
+	}
+	if replayed, err := p.Refund(ctx, RefundRequest{IntentID: intent.ID, Amount: 600, IdempotencyKey: "refund:1"}); err != nil || replayed.ID != first.ID {
+		t.Fatalf("retried refund %v (%v), want replay of %s", replayed, err, first.ID)
+	}
+	if _, err := p.Refund(ctx, RefundRequest{IntentID: intent.ID, Amount: 401}); !errors.Is(err, ErrInvalidRefund) {
+		t.Fatalf("over-refund: %v, want ErrInvalidRefund", err)
+	}
+	if _, err := p.Refund(ctx, RefundRequest{IntentID: intent.ID, Amount: 400}); err != nil {
+		t.Fatalf("refunding the remaining 400: %v", err)
+	}
+	if _, err := p.Refund(ctx, RefundRequest{IntentID: "pi_unknown", Amount: 100}); !errors.Is(err, ErrIntentNotFound) {
+		t.Fatalf("refund of unknown intent: %v, want ErrIntentNotFound", err)
+	}
+}
+
+func TestMockCaptureDeclined(t *testing.T) {
+	ctx := context.Background()
+	p := NewMockProvider("secret")
+
+	intent, err := p.CreateIntent(ctx, IntentRequest{Amount: 1000, Currency: "usd", Metadata: map[string]string{"simulate": "decline"}})
+	if err != nil {
+		t.Fatalf("creating intent: %v", err)
+	}
+	if _, err := p.Capture(ctx, intent.ID); !errors.Is(err, ErrPaymentFailed) {
+		t.Fatalf("capture: %v, want ErrPaymentFailed", err)
+	}
+	if failed, _ := p.GetIntent(ctx, intent.ID); failed.Status != IntentFailed || failed.CapturedAmount != 0 {
+		t.Fatalf("declined intent %s with %d captured, want failed with nothing", failed.Status, failed.CapturedAmount)
+	}
+}
+
+func TestMockCancel(t *testing.T) {
+	ctx := context.Background()
+	p := NewMockProvider("secret")
+
+	intent, _ := p.CreateIntent(ctx, IntentRequest{Amount: 1000, Currency: "usd"})
+	if canceled, err := p.Cancel(ctx, intent.ID); err != nil || canceled.Status != IntentCanceled {
+		t.Fatalf("cancel: %v (%v), want canceled", canceled, err)
+	}
+	if _, err := p.Capture(ctx, intent.ID); !errors.Is(err, ErrPaymentFailed) {
+		t.Fatalf("capture after cancel: %v, want ErrPaymentFailed", err)
+	}
+
+	captured, _ := p.CreateIntent(ctx, IntentRequest{Amount: 1000, Currency: "usd"})
+	p.Capture(ctx, captured.ID)
+	if _, err := p.Cancel(ctx, captured.ID); !errors.Is(err, ErrIntentCaptured) {
+		t.Fatalf("cancel after capture: %v, want ErrIntentCaptured", err)
+	}
+}
+
+func TestMockVerifyWebhook(t *testing.T) {
+	p := NewMockProvider("secret")
+	payload := []byte(`{"id":"evt_1","type":"payment.succeeded","intent_id":"pi_1","amount":1000}`)
+	now := time.Now()
+
+	event, err := p.VerifyWebhook(payload, p.SignWebhook(payload, now))
+	if err != nil {
+		t.Fatalf("valid signature: %v", err)
+	}
+	if event.ID != "evt_1" || event.Type != EventPaymentSucceeded || event.IntentID != "pi_1" || event.Amount != 1000 {
+		t.Fatalf("decoded %+v", event)
+	}
+
+	for name, signature := range map[string]string{
+		"tampered payload": p.SignWebhook([]byte(`{"id":"evt_2"}`), now),
+		"other secret":     NewMockProvider("other").SignWebhook(payload, now),
+		"stale timestamp":  p.SignWebhook(payload, now.Add(-2*webhookTolerance)),
+		"future timestamp": p.SignWebhook(payload, now.Add(2*webhookTolerance)),
+		"missing v1":       "t=" + strconv.FormatInt(now.Unix(), 10),
+		"missing t":        "v1=deadbeef",
+		"empty":            "",
+	} {
+		if _, err := p.VerifyWebhook(payload, signature); !errors.Is(err, ErrBadSignature) {
+			t.Errorf("%s: %v, want ErrBadSignature", name, err)
+		}
+	}
+
+	// Without a secret every webhook is rejected, even one signed with the empty key
+	unconfigured := NewMockProvider("")
+	if _, err := unconfigured.VerifyWebhook(payload, unconfigured.SignWebhook(payload, now)); !errors.Is(err, ErrBadSignature) {
+		t.Fatalf("empty secret: %v, want ErrBadSignature", err)
+	}
+
+	if _, err := p.VerifyWebhook([]byte(`{"type":"payment.succeeded"}`), p.SignWebhook([]byte(`{"type":"payment.succeeded"}`), now)); err == nil {
+		t.Fatal("accepted an event without an ID")
+	}
+}
@@ -0,0 +1,137 @@
+package payments
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math"
+	"os"
+	"time"
+)
+
+// Payment intent statuses
+const (
+	IntentRequiresCapture = "requires_capture"
+	IntentSucceeded       = "succeeded"
+	IntentFailed          = "failed"
+	IntentCanceled        = "canceled"
+)
+
+// Webhook event types
+const (
+	EventPaymentSucceeded = "payment.succeeded"
+	EventPaymentFailed    = "payment.failed"
+	EventPaymentRefunded  = "payment.refunded"
+	EventPaymentDisputed  = "payment.disputed"
+)
+
+// WebhookSignatureHeader carries the signature of a webhook payload
+const WebhookSignatureHeader = "Payment-Signature"
+
+var (
+	ErrIntentNotFound = errors.New("payment intent not found")
+	ErrAmountMismatch = errors.New("payment amount does not match the booking total")
+	ErrPaymentFailed  = errors.New("payment was declined")
+	ErrInvalidRefund  = errors.New("refund amount exceeds the captured amount")
+	ErrIntentCaptured = errors.New("payment intent was already captured")
+	ErrBadSignature   = errors.New("webhook signature verification failed")
+)
+
+// Provider is implemented by every payment gateway integration.
+// All amounts are in the currency's minor unit (cents).
+type Provider interface {
+	// Name identifies the provider in stored payment references
+	Name() string
+	// CreateIntent starts a payment for the given amount
+	CreateIntent(ctx context.Context, req IntentRequest) (*Intent, error)
+	// GetIntent returns the current state of a payment intent
+	GetIntent(ctx context.Context, intentID string) (*Intent, error)
+	// Capture collects an authorized payment
+	Capture(ctx context.Context, intentID string) (*Intent, error)
+	// Cancel voids an uncaptured payment; captured payments fail with ErrIntentCaptured
+	Cancel(ctx context.Context, intentID string) (*Intent, error)
+	// Refund returns part or all of a captured payment
+	Refund(ctx context.Context, req RefundRequest) (*Refund, error)
+	// VerifyWebhook checks a webhook signature and decodes its event
+	VerifyWebhook(payload []byte, signature string) (*WebhookEvent, error)
+}
+
+// IntentRequest describes a payment to be collected
+type IntentRequest struct {
+	Amount         int64
+	Currency       string
+	Reference      string // Our booking reference
+	IdempotencyKey string
+	Metadata       map[string]string
+}
+
+// Intent is a provider-side payment
+type Intent struct {
+	ID             string
+	Amount         int64
+	CapturedAmount int64
+	Currency       string
+	Status         string
+	Reference      string
+	PaymentMethod  string
+	CreatedAt      time.Time
+}
+
+// RefundRequest describes a refund of a captured payment
+type RefundRequest struct {
+	IntentID       string
+	Amount         int64
+	Reason         string
+	IdempotencyKey string
+}
+
+// Refund is a provider-side refund
+type Refund struct {
+	ID        string
+	IntentID  string
+	Amount    int64
+	Status    string
+	CreatedAt time.Time
+}
+
+// WebhookEvent is a verified provider callback
+type WebhookEvent struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	IntentID  string    `json:"intent_id"`
+	Amount    int64     `json:"amount"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ToMinorUnits converts an amount such as 12.34 into 1234
+func ToMinorUnits(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+// FromMinorUnits converts an amount such as 1234 into 12.34
+func FromMinorUnits(amount int64) float64 {
+	return float64(amount) / 100
+}
+
+// NewProviderFromEnv creates the provider selected by PAYMENT_PROVIDER.
+// Both the provider and its webhook secret must be configured explicitly.
+func NewProviderFromEnv() Provider {
+	secret := os.Getenv("PAYMENT_WEBHOOK_SECRET")
+	if secret == "" {
+		log.Fatal("PAYMENT_WEBHOOK_SECRET environment variable must be set. Please add it to your .env file.")
+	}
+
+	name := os.Getenv("PAYMENT_PROVIDER")
+	switch name {
+	case "mock":
+		log.Printf("[PAYMENTS] Using mock payment provider")
+		return NewMockProvider(secret)
+	case "":
+		log.Fatal("PAYMENT_PROVIDER environment variable must be set, e.g. PAYMENT_PROVIDER=mock for development. Please add it to your .env file.")
+		return nil
+	default:
+		log.Fatalf("Unknown PAYMENT_PROVIDER %q", name)
+		return nil
+	}
+}
@@ -0,0 +1,175 @@
+package services
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/tejas161/Cinema-Flix/models"
+)
+
+// Seat map geometry, in SVG user units
+const (
+	seatMapSeatSize   = 28
+	seatMapPitch      = 34 // Seat size plus the gap between seats
+	seatMapMargin     = 48 // Room for the row labels on both sides
+	seatMapScreenArea = 90
+	seatMapLegendRow  = 24
+	seatMapLegendCell = 130 // Width of a legend entry; four fit on a line
+)
+
+// Colors of price tiers, cheapest first; tiers past the end reuse the last color
+var seatMapTierColors = []string{"#43a047", "#1e88e5", "#8e24aa", "#fb8c00", "#d81b60", "#00897b"}
+
+// Colors of seats that cannot be booked, by status
+var seatMapStatusColors = map[string]string{
+	models.SeatHeld:        "#bdbdbd",
+	models.SeatBooked:      "#9e9e9e",
+	models.SeatBlocked:     "#78909c",
+	models.SeatMaintenance: "#e53935",
+}
+
+// seatMapLegend lists the statuses in the order they appear in the legend
+var seatMapLegend = []struct {
+	status string
+	label  string
+}{
+	{models.SeatBooked, "Booked"},
+	{models.SeatHeld, "Held"},
+	{models.SeatBlocked, "Blocked"},
+	{models.SeatMaintenance, "Maintenance"},
+}
+
+// RenderSeatMapSVG draws a showtime's seats as an SVG: the screen at the top, a row
+// label at both ends of every row, available seats colored by price tier and other
+// seats by status. Each seat carries its seat ID, label, price and status so partners
+// can make the map interactive.
+func RenderSeatMapSVG(showtime *models.Showtime, screenName string) []byte {
+	grid := models.BuildSeatGrid(showtime.Seats)
+	tiers := seatMapTiers(showtime.Seats)
+
+	// Center the seats, leaving room for the legend under narrow layouts
+	seatsWidth := grid.Columns * seatMapPitch
+	width := 2*seatMapMargin + max(seatsWidth, 4*seatMapLegendCell)
+	seatsLeft := (width - seatsWidth) / 2
+	legendTop := seatMapScreenArea + len(grid.Rows)*seatMapPitch + 24
+	legendLines := (len(tiers) + len(seatMapLegend) + 3) / 4
+	height := legendTop + legendLines*seatMapLegendRow + 12
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		width, height, width, height)
+	fmt.Fprintf(&buf, `<title>Seat map %s</title>`+"\n", escapeSVG(screenName))
+	fmt.Fprintf(&buf, `<rect width="%d" height="%d" fill="#ffffff"/>`+"\n", width, height)
+
+	// The screen is drawn as a curve across the seating area
+	fmt.Fprintf(&buf, `<path d="M %d 40 Q %d 16 %d 40" fill="none" stroke="#424242" stroke-width="4"/>`+"\n",
+		seatsLeft, width/2, seatsLeft+seatsWidth)
+	label := "SCREEN"
+	if screenName != "" {
+		label = "SCREEN · " + screenName
+	}
+	fmt.Fprintf(&buf, `<text x="%d" y="62" font-size="12" fill="#616161" text-anchor="middle">%s</text>`+"\n",
+		width/2, escapeSVG(label))
+
+	for i, row := range grid.Rows {
+		y := seatMapScreenArea + i*seatMapPitch
+		labelY := y + seatMapSeatSize/2 + 4
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-size="12" font-weight="bold" fill="#424242" text-anchor="middle">%s</text>`+"\n",
+			seatsLeft-seatMapMargin/2, labelY, escapeSVG(row.RowID))
+		fmt.Fprintf(&buf, `<text x="%d" y="%d" font-size="12" font-weight="bold" fill="#424242" text-anchor="middle">%s</text>`+"\n",
+			seatsLeft+seatsWidth+seatMapMargin/2, labelY, escapeSVG(row.RowID))
+
+		for _, cell := range row.Cells {
+			if cell.Seat != nil {
+				writeSeatMapSeat(&buf, cell.Seat, seatsLeft+(cell.Column-1)*seatMapPitch, y, tiers)
+			}
+		}
+	}
+
+	writeSeatMapLegend(&buf, tiers, legendTop)
+
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// writeSeatMapSeat draws one seat; wheelchair spaces are round
+func writeSeatMapSeat(buf *bytes.Buffer, seat *models.Seat, x, y int, tiers []float64) {
+	fill, ok := seatMapStatusColors[seat.Status]
+	if !ok {
+		fill = seatMapTierColor(tiers, seat.SeatBasePrice())
+	}
+
+	label := seat.Label
+	if label == "" {
+		label = strconv.Itoa(seat.SeatNumber)
+	}
+	kind := seat.Kind
+	if kind == "" {
+		kind = models.SeatKindStandard
+	}
+
+	fmt.Fprintf(buf, `<g class="seat seat-%s seat-%s" data-seat-id="%s" data-status="%s" data-price="%.2f" data-kind="%s">`,
+		escapeSVG(seat.Status), escapeSVG(kind), escapeSVG(seat.SeatID), escapeSVG(seat.Status), seat.Price, escapeSVG(kind))
+	fmt.Fprintf(buf, `<title>%s · %s · %s</title>`, escapeSVG(seat.SeatID), formatTicketAmount(seat.Price), escapeSVG(seat.Status))
+	if seat.Kind == models.SeatKindWheelchair {
+		fmt.Fprintf(buf, `<circle cx="%d" cy="%d" r="%d" fill="%s"/>`, x+seatMapSeatSize/2, y+seatMapSeatSize/2, seatMapSeatSize/2, fill)
+	} else {
+		fmt.Fprintf(buf, `<rect x="%d" y="%d" width="%d" height="%d" rx="5" fill="%s"/>`, x, y, seatMapSeatSize, seatMapSeatSize, fill)
+	}
+	fmt.Fprintf(buf, `<text x="%d" y="%d" font-size="10" fill="#ffffff" text-anchor="middle">%s</text>`,
+		x+seatMapSeatSize/2, y+seatMapSeatSize/2+4, escapeSVG(label))
+	buf.WriteString("</g>\n")
+}
+
+// writeSeatMapLegend explains the price tiers and status colors, four entries per line
+func writeSeatMapLegend(buf *bytes.Buffer, tiers []float64, top int) {
+	type entry struct {
+		color string
+		label string
+	}
+	var entries []entry
+	for _, price := range tiers {
+		entries = append(entries, entry{seatMapTierColor(tiers, price), formatTicketAmount(price)})
+	}
+	for _, status := range seatMapLegend {
+		entries = append(entries, entry{seatMapStatusColors[status.status], status.label})
+	}
+
+	for i, e := range entries {
+		x := seatMapMargin + (i%4)*seatMapLegendCell
+		y := top + (i/4)*seatMapLegendRow
+		fmt.Fprintf(buf, `<rect x="%d" y="%d" width="14" height="14" rx="3" fill="%s"/>`, x, y, e.color)
+		fmt.Fprintf(buf, `<text x="%d" y="%d" font-size="11" fill="#424242">%s</text>`+"\n", x+20, y+11, escapeSVG(e.label))
+	}
+}
+
+// seatMapTiers returns the distinct seat base prices, cheapest first.
+// Base prices keep dynamic pricing from splitting a tier into many colors.
+func seatMapTiers(seats []models.Seat) []float64 {
+	seen := make(map[float64]bool)
+	var tiers []float64
+	for _, seat := range seats {
+		if price := seat.SeatBasePrice(); !seen[price] {
+			seen[price] = true
+			tiers = append(tiers, price)
+		}
+	}
+	sort.Float64s(tiers)
+	return tiers
+}
+
+// seatMapTierColor returns the color of the tier a price belongs to
+func seatMapTierColor(tiers []float64, price float64) string {
+	tier := sort.SearchFloat64s(tiers, price)
+	return seatMapTierColors[min(tier, len(seatMapTierColors)-1)]
+}
+
+// escapeSVG escapes text for use in SVG content and attribute values
+func escapeSVG(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/tejas161/Cinema-Flix/db"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+)
+
+var ErrSessionInvalid = errors.New("session is invalid or has expired")
+
+// SessionService issues, validates and revokes signed login sessions.
+// The token handed to the browser only carries the session identifier;
+// the session record in MongoDB remains the source of truth so that
+// logging out revokes the token immediately.
+type SessionService struct {
+	config   *config.SessionConfig
+	signer   *TokenSigner
+	sessions *db.SessionRepository
+	users    *db.UserRepository
+}
+
+// sessionClaims is the data embedded in a session token
+type sessionClaims struct {
+	SessionID string `json:"sid"`
+	UserID    string `json:"uid"`
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(cfg *config.SessionConfig) *SessionService {
+	return &SessionService{
+		config:   cfg,
+		signer:   NewTokenSigner(cfg.Secret, "session"),
+		sessions: db.NewSessionRepository(),
+		users:    db.NewUserRepository(),
+	}
+}
+
+// Config returns the session configuration
+func (s *SessionService) Config() *config.SessionConfig {
+	return s.config
+}
+
+// CreateSession stores a new session for the user and returns its signed token
+func (s *SessionService) CreateSession(ctx context.Context, user *models.User, userAgent, ip string) (string, *models.Session, error) {
+	sessionID, err := RandomToken(32)
+	if err != nil {
+		return "", nil, err
+	}
+
+	session := models.NewSession(sessionID, user.ID, userAgent, ip, s.config.TTL)
+	if err := s.sessions.CreateSession(ctx, session); err != nil {
+		return "", nil, err
+	}
+
+	token, err := s.signer.Sign(sessionClaims{SessionID: sessionID, UserID: user.ID.Hex()}, session.ExpiresAt)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return token, session, nil
+}
+
+// ValidateSession verifies a session token and returns the session and its user
+func (s *SessionService) ValidateSession(ctx context.Context, token string) (*models.Session, *models.User, error) {
+	var claims sessionClaims
+	if err := s.signer.Verify(token, &claims); err != nil {
+		return nil, nil, ErrSessionInvalid
+	}
+
+	session, err := s.sessions.FindSessionByID(ctx, claims.SessionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if session == nil || !session.IsActive() || session.UserID.Hex() != claims.UserID {
+		return nil, nil, ErrSessionInvalid
+	}
+
+	user, err := s.users.FindUserByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load session user: %w", err)
+	}
+	if user == nil {
+		return nil, nil, ErrSessionInvalid
+	}
+
+	return session, user, nil
+}
+
+// RevokeSession revokes the session referenced by a token
+func (s *SessionService) RevokeSession(ctx context.Context, token string) error {
+	var claims sessionClaims
+	if err := s.signer.Verify(token, &claims); err != nil {
+		if errors.Is(err, ErrTokenExpired) {
+			return nil // Nothing left to revoke
+		}
+		return ErrSessionInvalid
+	}
+
+	return s.sessions.RevokeSession(ctx, claims.SessionID)
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/tejas161/Cinema-Flix/internal/config"
+	"github.com/tejas161/Cinema-Flix/models"
+)
+
+// ticketValidAfterShow keeps tickets scannable for late arrivals
+const ticketValidAfterShow = 6 * time.Hour
+
+// TicketClaims is the data embedded in a ticket token
+type TicketClaims struct {
+	BookingID string   `json:"bid"` // Database ID of the booking
+	Reference string   `json:"ref"` // Public booking ID printed on the ticket
+	SeatIDs   []string `json:"seats"`
+}
+
+// TicketService issues and verifies the signed tokens encoded in ticket QR codes.
+// The token only proves which booking and seats it was issued for; the booking
+// record decides whether the ticket is still valid at the door.
+type TicketService struct {
+	signer *TokenSigner
+}
+
+// NewTicketService creates a new ticket service
+func NewTicketService(cfg *config.SessionConfig) *TicketService {
+	return &TicketService{
+		signer: NewTokenSigner(cfg.Secret, "ticket"),
+	}
+}
+
+// TicketToken returns the signed token for a booking's seats
+func (s *TicketService) TicketToken(booking *models.Booking) (string, error) {
+	seatIDs := make([]string, 0, len(booking.Seats))
+	for _, seat := range booking.Seats {
+		seatIDs = append(seatIDs, seat.SeatID)
+	}
+
+	claims := TicketClaims{
+		BookingID: booking.ID.Hex(),
+		Reference: booking.BookingID,
+		SeatIDs:   seatIDs,
+	}
+	return s.signer.Sign(claims, booking.ShowTime.Add(ticketValidAfterShow))
+}
+
+// TicketQRCode renders the booking's ticket token as a PNG QR code
+func (s *TicketService) TicketQRCode(booking *models.Booking, size int) ([]byte, error) {
+	token, err := s.TicketToken(booking)
+	if err != nil {
+		return nil, err
+	}
+
+	png, err := qrcode.Encode(token, qrcode.Medium, size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render ticket QR code: %w", err)
+	}
+	return png, nil
+}
+
+// VerifyTicket checks a scanned ticket token and returns its claims
+func (s *TicketService) VerifyTicket(token string) (*TicketClaims, error) {
+	var claims TicketClaims
+	if err := s.signer.Verify(token, &claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/go-pdf/fpdf"
+	"github.com/tejas161/Cinema-Flix/models"
+)
+
+// TicketPDF renders a printable A5 ticket with the booking details and its QR code.
+// The theater may be nil when it can no longer be found; an empty movie title
+// falls back to the TMDB ID.
+func (s *TicketService) TicketPDF(booking *models.Booking, theater *models.Theater, movieTitle string) ([]byte, error) {
+	qr, err := s.TicketQRCode(booking, 512)
+	if err != nil {
+		return nil, err
+	}
+
+	if movieTitle == "" {
+		movieTitle = fmt.Sprintf("Movie #%d", booking.MovieID)
+	}
+
+	theaterName, theaterAddress, screenName := "Cinema-Flix", "", ""
+	if theater != nil {
+		theaterName = theater.Name
+		theaterAddress = strings.TrimSpace(fmt.Sprintf("%s, %s, %s %s", theater.Address, theater.City, theater.State, theater.Pincode))
+		for _, screen := range theater.Screens {
+			if screen.ID == booking.ScreenID {
+				screenName = screen.Name
+			}
+		}
+	}
+
+	pdf := fpdf.New("P", "mm", "A5", "")
+	pdf.SetTitle("Ticket "+booking.BookingID, true)
+	pdf.SetAuthor("Cinema-Flix", true)
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 12)
+	pdf.AddPage()
+
+	// Core fonts are cp1252; translate so accented titles print correctly
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	width, _ := pdf.GetPageSize()
+	contentWidth := width - 24
+
+	// Header
+	pdf.SetFillColor(229, 9, 20)
+	pdf.Rect(0, 0, width, 22, "F")
+	pdf.SetTextColor(255, 255, 255)
+	pdf.SetFont("Helvetica", "B", 18)
+	pdf.SetXY(12, 6)
+	pdf.CellFormat(contentWidth, 10, "CINEMA-FLIX E-TICKET", "", 1, "L", false, 0, "")
+	pdf.SetTextColor(0, 0, 0)
+	pdf.SetY(28)
+
+	// Movie and show
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.MultiCell(contentWidth, 8, tr(movieTitle), "", "L", false)
+	pdf.SetFont("Helvetica", "", 11)
+	pdf.CellFormat(contentWidth, 6, theater.LocalTime(booking.ShowTime).Format("Monday, 02 January 2006 - 03:04 PM"), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(contentWidth, 6, tr(theaterName), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	if theaterAddress != "" {
+		pdf.MultiCell(contentWidth, 5, tr(theaterAddress), "", "L", false)
+	}
+	if screenName != "" {
+		pdf.CellFormat(contentWidth, 5, tr("Screen: "+screenName), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// QR code with the booking reference beside it
+	qrSize := 48.0
+	top := pdf.GetY()
+	pdf.RegisterImageOptionsReader("ticket-qr", fpdf.ImageOptions{ImageType: "PNG"}, bytes.NewReader(qr))
+	pdf.ImageOptions("ticket-qr", 12, top, qrSize, qrSize, false, fpdf.ImageOptions{ImageType: "PNG"}, 0, "")
+
+	pdf.SetXY(12+qrSize+6, top+4)
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, 5, "BOOKING ID", "", 2, "L", false, 0, "")
+	pdf.SetFont("Courier", "B", 14)
+	pdf.CellFormat(0, 7, booking.BookingID, "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, 5, fmt.Sprintf("%d seat(s)", len(booking.Seats)), "", 2, "L", false, 0, "")
+	pdf.CellFormat(0, 5, tr("Booked by "+booking.UserName), "", 2, "L", false, 0, "")
+	pdf.SetY(top + qrSize + 4)
+
+	// Seats
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(contentWidth, 7, "Seats", "B", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	for _, seat := range booking.Seats {
+		pdf.CellFormat(contentWidth/2, 6, fmt.Sprintf("%s  (%s)", seat.SeatID, seat.SeatType), "", 0, "L", false, 0, "")
+		pdf.CellFormat(contentWidth/2, 6, formatTicketAmount(seat.Price), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(2)
+
+	// Pricing breakdown
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(contentWidth, 7, "Payment", "B", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	lines := []struct {
+		label  string
+		amount float64
+	}{
+		{"Tickets", booking.Pricing.BaseAmount},
+		{"Convenience fee", booking.Pricing.ConvenienceFee},
+	}
+	if len(booking.Pricing.Taxes) > 0 {
+		for _, tax := range booking.Pricing.Taxes {
+			lines = append(lines, struct {
+				label  string
+				amount float64
+			}{fmt.Sprintf("%s (%g%%)", tax.Name, tax.Percent), tax.Amount})
+		}
+	} else {
+		lines = append(lines, struct {
+			label  string
+			amount float64
+		}{"Tax", booking.Pricing.Tax})
+	}
+	if booking.Pricing.Discount > 0 {
+		lines = append(lines, struct {
+			label  string
+			amount float64
+		}{"Discount", -booking.Pricing.Discount})
+	}
+	for _, line := range lines {
+		pdf.CellFormat(contentWidth/2, 6, line.label, "", 0, "L", false, 0, "")
+		pdf.CellFormat(contentWidth/2, 6, formatTicketAmount(line.amount), "", 1, "R", false, 0, "")
+	}
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(contentWidth/2, 7, "Total", "T", 0, "L", false, 0, "")
+	pdf.CellFormat(contentWidth/2, 7, formatTicketAmount(booking.Pricing.TotalAmount), "T", 1, "R", false, 0, "")
+	if refunded := booking.RefundedAmount(); refunded > 0 {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(contentWidth/2, 6, "Refunded", "", 0, "L", false, 0, "")
+		pdf.CellFormat(contentWidth/2, 6, formatTicketAmount(refunded), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	// Footer
+	pdf.SetFont("Helvetica", "I", 8)
+	pdf.SetTextColor(100, 100, 100)
+	pdf.MultiCell(contentWidth, 4, "Show this ticket at the entrance. The QR code admits each seat once. "+
+		"Please arrive 15 minutes before the show.", "", "L", false)
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, fmt.Errorf("failed to render ticket PDF: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// formatTicketAmount formats an amount for printing on a ticket
+func formatTicketAmount(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("-$%.2f", -amount)
+	}
+	return fmt.Sprintf("$%.2f", amount)
+}
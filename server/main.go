@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"time"
@@ -9,8 +10,11 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/joho/godotenv"
+	"github.com/tejas161/Cinema-Flix/db"
 	"github.com/tejas161/Cinema-Flix/internal/config"
 	"github.com/tejas161/Cinema-Flix/internal/routes"
+	"github.com/tejas161/Cinema-Flix/internal/services"
+	"github.com/tejas161/Cinema-Flix/internal/services/notifications"
 )
 
 func main() {
@@ -70,16 +74,32 @@ func main() {
 		}
 	}()
 
+	// Ensure collection indexes exist
+	if err := db.EnsureIndexes(); err != nil {
+		log.Fatalf("Failed to create MongoDB indexes: %v", err)
+	}
+
+	// Start background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	mailer := notifications.NewBookingMailer(config.NewMailConfig())
+	go mailer.Start(workerCtx)
+	go services.NewHoldReaper(mailer).Start(workerCtx)
+
+	pricer := services.NewDynamicPricer()
+	go pricer.Start(workerCtx)
+
 	// Add CORS middleware
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     clientURL,
-		AllowHeaders:     "Origin, Content-Type, Accept, X-User-ID, X-User-Email",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, Idempotency-Key",
 		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
 		AllowCredentials: true,
 	}))
 
 	// Register routes
-	routes.SetupRoutes(app)
+	routes.SetupRoutes(app, mailer, pricer)
 
 	log.Printf("[SERVER] Cinema Flix Backend Starting...")
 	log.Printf("  Port: %s", port)
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// MaxBestSeats is the most seats that can be picked automatically at once
+const MaxBestSeats = 10
+
+// ErrNotEnoughSeats is returned when too few seats of the requested type are available
+var ErrNotEnoughSeats = errors.New("not enough seats available")
+
+// SeatSelection is a set of seats picked for a customer
+type SeatSelection struct {
+	Seats      []Seat   `json:"seats"`
+	SeatIDs    []string `json:"seat_ids"`
+	Contiguous bool     `json:"contiguous"` // All seats sit next to each other in one row
+	Groups     int      `json:"groups"`     // Number of separate blocks the seats are split into
+}
+
+// seatBlock is a run of adjacent available seats in a row
+type seatBlock struct {
+	rowIndex int
+	seats    []Seat
+}
+
+// BestAvailableSeats picks quantity available seats of seatType (any type when empty).
+// Wheelchair spaces and companion seats are never picked. A block of adjacent seats
+// nearest the middle of the auditorium wins; when no row has
+// enough adjacent seats, the seats are split over as few blocks as possible, each again
+// as close to the middle as possible. Rows and columns follow the screen's seat layout,
+// which the showtime's seats are created from in order.
+func (s *Showtime) BestAvailableSeats(quantity int, seatType string) (*SeatSelection, error) {
+	if quantity < 1 || quantity > MaxBestSeats {
+		return nil, fmt.Errorf("quantity must be between 1 and %d", MaxBestSeats)
+	}
+
+	rows, widths := s.seatRows()
+	taken := make(map[string]bool)
+	remaining := quantity
+	var picked []Seat
+	groups := 0
+
+	for remaining > 0 {
+		blocks := availableBlocks(rows, seatType, taken)
+		if len(blocks) == 0 {
+			return nil, ErrNotEnoughSeats
+		}
+
+		// Take the largest window of each block that fits and keeps loveseat pairs together;
+		// a whole block may not, when half of a pair is already taken
+		best, bestScore := seatBlock{}, math.MaxFloat64
+		for _, block := range blocks {
+			for size := min(len(block.seats), remaining); size > 0; size-- {
+				found := false
+				for start := 0; start+size <= len(block.seats); start++ {
+					window := seatBlock{rowIndex: block.rowIndex, seats: block.seats[start : start+size]}
+					if window.splitsLoveseat() {
+						continue
+					}
+					found = true
+					if score := window.score(len(rows), widths[block.rowIndex]); betterBlock(window, score, best, bestScore) {
+						best, bestScore = window, score
+					}
+				}
+				if found {
+					break
+				}
+			}
+		}
+		if len(best.seats) == 0 {
+			return nil, ErrNotEnoughSeats
+		}
+
+		for _, seat := range best.seats {
+			taken[seat.SeatID] = true
+		}
+		picked = append(picked, best.seats...)
+		remaining -= len(best.seats)
+		groups++
+	}
+
+	selection := &SeatSelection{
+		Seats:      picked,
+		SeatIDs:    make([]string, 0, len(picked)),
+		Contiguous: groups == 1,
+		Groups:     groups,
+	}
+	for _, seat := range picked {
+		selection.SeatIDs = append(selection.SeatIDs, seat.SeatID)
+	}
+	return selection, nil
+}
+
+// seatRows groups the seats by row in layout order, each row sorted by grid column,
+// and returns the width of each row
+func (s *Showtime) seatRows() ([][]Seat, []int) {
+	index := make(map[string]int)
+	var rows [][]Seat
+	for _, seat := range s.Seats {
+		i, ok := index[seat.RowID]
+		if !ok {
+			i = len(rows)
+			index[seat.RowID] = i
+			rows = append(rows, nil)
+		}
+		rows[i] = append(rows[i], seat)
+	}
+
+	widths := make([]int, len(rows))
+	for i, row := range rows {
+		sortByColumn(row)
+		widths[i] = row[len(row)-1].GridColumn()
+	}
+	return rows, widths
+}
+
+// availableBlocks returns the runs of adjacent available seats of seatType that are not taken.
+// Seats across an aisle or a missing seat are not adjacent.
+func availableBlocks(rows [][]Seat, seatType string, taken map[string]bool) []seatBlock {
+	var blocks []seatBlock
+	for i, row := range rows {
+		var current []Seat
+		for _, seat := range row {
+			usable := seat.Status == SeatAvailable && !taken[seat.SeatID] && !seat.IsAccessible() &&
+				(seatType == "" || seat.SeatType == seatType)
+			adjacent := len(current) > 0 && current[len(current)-1].GridColumn() == seat.GridColumn()-1
+			if !usable || (len(current) > 0 && !adjacent) {
+				if len(current) > 0 {
+					blocks = append(blocks, seatBlock{rowIndex: i, seats: current})
+				}
+				current = nil
+			}
+			if usable {
+				current = append(current, seat)
+			}
+		}
+		if len(current) > 0 {
+			blocks = append(blocks, seatBlock{rowIndex: i, seats: current})
+		}
+	}
+	return blocks
+}
+
+// splitsLoveseat reports whether the block holds only one half of a loveseat
+func (b seatBlock) splitsLoveseat() bool {
+	inBlock := make(map[string]bool, len(b.seats))
+	for _, seat := range b.seats {
+		inBlock[seat.SeatID] = true
+	}
+	for _, seat := range b.seats {
+		if seat.PairedWith != "" && !inBlock[seat.PairedWith] {
+			return true
+		}
+	}
+	return false
+}
+
+// score measures how far a block sits from the middle of the auditorium; lower is better.
+// Row and column distances are relative to the layout's size so both count alike.
+func (b seatBlock) score(rowCount, rowWidth int) float64 {
+	rowCenter := float64(rowCount-1) / 2
+	rowDistance := math.Abs(float64(b.rowIndex)-rowCenter) / math.Max(float64(rowCount), 1)
+
+	first, last := b.seats[0].GridColumn(), b.seats[len(b.seats)-1].GridColumn()
+	columnCenter := float64(rowWidth+1) / 2
+	columnDistance := math.Abs(float64(first+last)/2-columnCenter) / math.Max(float64(rowWidth), 1)
+
+	return rowDistance + columnDistance
+}
+
+// betterBlock prefers larger blocks, so split selections use as few blocks as possible,
+// then more central ones, then earlier rows
+func betterBlock(candidate seatBlock, score float64, best seatBlock, bestScore float64) bool {
+	switch {
+	case len(best.seats) == 0:
+		return true
+	case len(candidate.seats) != len(best.seats):
+		return len(candidate.seats) > len(best.seats)
+	case score != bestScore:
+		return score < bestScore
+	default:
+		return candidate.rowIndex < best.rowIndex
+	}
+}
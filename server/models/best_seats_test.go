@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// layoutShowtime creates a morning showtime with the seats of the given rows
+func layoutShowtime(rows ...SeatRow) *Showtime {
+	theater := &Theater{}
+	screen := &Screen{SeatLayout: SeatLayout{Rows: rows}}
+	return &Showtime{Seats: theater.InitializeSeats(screen, time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC))}
+}
+
+// bestSeats runs BestAvailableSeats, failing the test if it does not return promptly
+func bestSeats(t *testing.T, showtime *Showtime, quantity int, seatType string) (*SeatSelection, error) {
+	t.Helper()
+
+	type result struct {
+		selection *SeatSelection
+		err       error
+	}
+	done := make(chan result, 1)
+	go func() {
+		selection, err := showtime.BestAvailableSeats(quantity, seatType)
+		done <- result{selection, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.selection, r.err
+	case <-time.After(2 * time.Second):
+		t.Fatalf("BestAvailableSeats(%d, %q) did not return", quantity, seatType)
+		return nil, nil
+	}
+}
+
+func TestBestAvailableSeatsLoveseatOnlyRow(t *testing.T) {
+	showtime := layoutShowtime(SeatRow{
+		RowID:   "A",
+		RowType: "premium",
+		Seats: []SeatSpec{
+			{Kind: SeatKindLoveseat, PairedWith: "2"},
+			{Kind: SeatKindLoveseat, PairedWith: "1"},
+		},
+		Price: Price{Morning: 200},
+	})
+
+	if _, err := bestSeats(t, showtime, 1, ""); !errors.Is(err, ErrNotEnoughSeats) {
+		t.Fatalf("quantity 1: got %v, want ErrNotEnoughSeats", err)
+	}
+
+	selection, err := bestSeats(t, showtime, 2, "")
+	if err != nil {
+		t.Fatalf("quantity 2: %v", err)
+	}
+	if want := []string{"A1", "A2"}; !reflect.DeepEqual(selection.SeatIDs, want) {
+		t.Fatalf("quantity 2: got %v, want %v", selection.SeatIDs, want)
+	}
+}
+
+func TestBestAvailableSeatsSkipsHalfTakenLoveseat(t *testing.T) {
+	showtime := layoutShowtime(SeatRow{
+		RowID:   "A",
+		RowType: "regular",
+		Seats: []SeatSpec{
+			{Kind: SeatKindLoveseat, PairedWith: "2"},
+			{Kind: SeatKindLoveseat, PairedWith: "1"},
+			{},
+			{},
+		},
+		Price: Price{Morning: 100},
+	})
+	showtime.Seats[0].Status = SeatBooked
+
+	selection, err := bestSeats(t, showtime, 2, "")
+	if err != nil {
+		t.Fatalf("got %v", err)
+	}
+	if want := []string{"A3", "A4"}; !reflect.DeepEqual(selection.SeatIDs, want) {
+		t.Fatalf("got %v, want %v", selection.SeatIDs, want)
+	}
+
+	if _, err := bestSeats(t, showtime, 3, ""); !errors.Is(err, ErrNotEnoughSeats) {
+		t.Fatalf("quantity 3: got %v, want ErrNotEnoughSeats", err)
+	}
+}
+
+func TestBestAvailableSeatsPrefersCenter(t *testing.T) {
+	showtime := layoutShowtime(
+		SeatRow{RowID: "A", RowType: "regular", SeatCount: 6, Price: Price{Morning: 100}},
+		SeatRow{RowID: "B", RowType: "regular", SeatCount: 6, Price: Price{Morning: 100}},
+		SeatRow{RowID: "C", RowType: "regular", SeatCount: 6, Price: Price{Morning: 100}},
+	)
+
+	selection, err := bestSeats(t, showtime, 2, "")
+	if err != nil {
+		t.Fatalf("got %v", err)
+	}
+	if want := []string{"B3", "B4"}; !reflect.DeepEqual(selection.SeatIDs, want) || !selection.Contiguous {
+		t.Fatalf("got %v (contiguous %v), want %v", selection.SeatIDs, selection.Contiguous, want)
+	}
+}
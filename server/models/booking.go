@@ -1,6 +1,7 @@
 package models
 
 import (
+	"math"
 	"time"
 
 	"go.mongodb.org/mongo-driver/v2/bson"
@@ -23,10 +24,21 @@ type Booking struct {
 	Seats           []BookedSeat  `bson:"seats" json:"seats"`                     // Booked seats
 	TotalSeats      int           `bson:"total_seats" json:"total_seats"`         // Number of seats booked
 	Pricing         BookingPricing `bson:"pricing" json:"pricing"`               // Pricing breakdown
-	PaymentStatus   string        `bson:"payment_status" json:"payment_status"`   // pending, completed, failed, refunded
-	BookingStatus   string        `bson:"booking_status" json:"booking_status"`   // confirmed, cancelled, expired
+	Promotion       *AppliedPromotion `bson:"promotion,omitempty" json:"promotion,omitempty"`            // Promo code redeemed, if any
+	PricingRule     *PricingRuleSnapshot `bson:"pricing_rule,omitempty" json:"pricing_rule,omitempty"`   // Fee and tax rule the booking was priced with
+	PriceMultiplier float64       `bson:"price_multiplier,omitempty" json:"price_multiplier,omitempty"` // Dynamic pricing multiplier the seats were sold at
+	PriceChangeID   *bson.ObjectID `bson:"price_change_id,omitempty" json:"price_change_id,omitempty"`  // Dynamic price change in effect when booked
+	PaymentStatus   string        `bson:"payment_status" json:"payment_status"`   // pending, completed, failed, refunded, partially_refunded, disputed
+	BookingStatus   string        `bson:"booking_status" json:"booking_status"`   // confirmed, cancelled, expired, exchanged
 	PaymentMethod   string        `bson:"payment_method" json:"payment_method"`   // card, wallet, upi, netbanking
 	TransactionID   string        `bson:"transaction_id" json:"transaction_id"`   // Payment gateway transaction ID
+	PaymentProvider string        `bson:"payment_provider,omitempty" json:"payment_provider,omitempty"`   // Payment gateway used
+	PaymentIntentID string        `bson:"payment_intent_id,omitempty" json:"payment_intent_id,omitempty"` // Provider payment intent
+	Charges         []PaymentCharge `bson:"charges,omitempty" json:"charges,omitempty"`                   // Payments captured for the booking
+	Refunds         []Refund      `bson:"refunds,omitempty" json:"refunds,omitempty"`                     // Money returned on cancellation
+	Amendments      []BookingAmendment `bson:"amendments,omitempty" json:"amendments,omitempty"`          // Changes made after booking
+	ExchangedFrom   string        `bson:"exchanged_from,omitempty" json:"exchanged_from,omitempty"`       // Booking ID this booking replaced
+	ExchangedTo     string        `bson:"exchanged_to,omitempty" json:"exchanged_to,omitempty"`           // Booking ID that replaced this booking
 	BookedAt        time.Time     `bson:"booked_at" json:"booked_at"`             // When booking was made
 	ExpiresAt       time.Time     `bson:"expires_at" json:"expires_at"`           // When booking expires (if unpaid)
 	CreatedAt       time.Time     `bson:"created_at" json:"created_at"`
@@ -40,6 +52,8 @@ type BookedSeat struct {
 	SeatNumber int     `bson:"seat_number" json:"seat_number"` // 1, 2, 3, etc.
 	SeatType   string  `bson:"seat_type" json:"seat_type"`     // premium, regular
 	Price      float64 `bson:"price" json:"price"`             // Price paid for this seat
+	CheckedInAt *time.Time `bson:"checked_in_at,omitempty" json:"checked_in_at,omitempty"` // When the ticket was scanned at the door
+	CheckedInBy string     `bson:"checked_in_by,omitempty" json:"checked_in_by,omitempty"` // Staff member who scanned it
 }
 
 // BookingPricing represents the pricing breakdown for a booking
@@ -47,11 +61,38 @@ type BookingPricing struct {
 	BaseAmount      float64 `bson:"base_amount" json:"base_amount"`           // Total base price of seats
 	ConvenienceFee  float64 `bson:"convenience_fee" json:"convenience_fee"`   // Platform convenience fee
 	Tax             float64 `bson:"tax" json:"tax"`                           // Tax amount
+	Taxes           []TaxAmount `bson:"taxes,omitempty" json:"taxes,omitempty"` // Tax amount by component
 	Discount        float64 `bson:"discount" json:"discount"`                 // Discount applied
 	TotalAmount     float64 `bson:"total_amount" json:"total_amount"`         // Final amount to be paid
 	PaidAmount      float64 `bson:"paid_amount" json:"paid_amount"`           // Amount actually paid
 }
 
+// PaymentCharge is a payment captured for a booking. A booking that replaced another in an
+// exchange carries the original's charges plus the charge for any price increase.
+type PaymentCharge struct {
+	IntentID       string    `bson:"intent_id" json:"intent_id"`             // Provider payment intent
+	Amount         float64   `bson:"amount" json:"amount"`                   // Amount captured
+	RefundedAmount float64   `bson:"refunded_amount" json:"refunded_amount"` // Amount returned so far
+	CapturedAt     time.Time `bson:"captured_at" json:"captured_at"`
+}
+
+// Booking amendment types
+const (
+	AmendmentSeatsRemoved = "seats_removed"
+)
+
+// BookingAmendment records a change made to a booking after it was created
+type BookingAmendment struct {
+	ID              bson.ObjectID  `bson:"_id" json:"id"`
+	Type            string         `bson:"type" json:"type"`                                 // seats_removed
+	SeatIDs         []string       `bson:"seat_ids" json:"seat_ids"`                         // Seats affected by the change
+	PreviousPricing BookingPricing `bson:"previous_pricing" json:"previous_pricing"`         // Pricing before the change
+	Pricing         BookingPricing `bson:"pricing" json:"pricing"`                           // Pricing after the change
+	RefundID        *bson.ObjectID `bson:"refund_id,omitempty" json:"refund_id,omitempty"`   // Refund issued for the change
+	AmendedBy       string         `bson:"amended_by" json:"amended_by"`                     // User who made the change
+	CreatedAt       time.Time      `bson:"created_at" json:"created_at"`
+}
+
 // CustomerInfo represents customer information for booking
 type CustomerInfo struct {
 	Name  string `bson:"name" json:"name"`
@@ -67,10 +108,13 @@ type GoogleUserInfo struct {
 	Picture  string `json:"picture"`
 }
 
+// BookingHoldDuration is how long seats stay held for an unpaid booking
+const BookingHoldDuration = 15 * time.Minute
+
 // NewBooking creates a new Booking instance with Google user data
 func NewBooking(bookingID string, userInfo GoogleUserInfo, showtimeID, movieID, theaterID, screenID interface{}, showDate, showTime time.Time) *Booking {
 	now := time.Now()
-	expiresAt := now.Add(15 * time.Minute) // Booking expires in 15 minutes if not paid
+	expiresAt := now.Add(BookingHoldDuration) // Booking expires in 15 minutes if not paid
 	
 	// Convert interfaces to appropriate types
 	var (
@@ -122,7 +166,29 @@ func (b *Booking) UpdateTimestamp() {
 
 // IsExpired checks if the booking has expired
 func (b *Booking) IsExpired() bool {
-	return time.Now().After(b.ExpiresAt) && b.PaymentStatus == "pending"
+	return b.BookingStatus == "expired" || (time.Now().After(b.ExpiresAt) && b.PaymentStatus == "pending")
+}
+
+// IsOwnedBy checks if the booking belongs to the user.
+// Older bookings stored the database user ID instead of the Google ID.
+func (b *Booking) IsOwnedBy(user *User) bool {
+	if user == nil || b.GoogleUserID == "" {
+		return false
+	}
+	return b.GoogleUserID == user.GoogleID || b.GoogleUserID == user.ID.Hex()
+}
+
+// IsValidTicket checks if the booking admits its holder to the show
+func (b *Booking) IsValidTicket() bool {
+	if b.BookingStatus != "confirmed" {
+		return false
+	}
+	return b.PaymentStatus == "completed" || b.PaymentStatus == "partially_refunded"
+}
+
+// OwnerIDs returns the values GoogleUserID may hold for bookings owned by the user
+func OwnerIDs(user *User) []string {
+	return []string{user.GoogleID, user.ID.Hex()}
 }
 
 // AddSeat adds a seat to the booking
@@ -139,29 +205,65 @@ func (b *Booking) AddSeat(seatID, rowID string, seatNumber int, seatType string,
 	b.UpdateTimestamp()
 }
 
-// CalculatePricing calculates the total pricing for the booking
-func (b *Booking) CalculatePricing(convenienceFeePercent, taxPercent, discount float64) {
+// RemoveSeats drops the given seats from the booking and returns the removed seats
+func (b *Booking) RemoveSeats(seatIDs []string) []BookedSeat {
+	remove := make(map[string]bool, len(seatIDs))
+	for _, seatID := range seatIDs {
+		remove[seatID] = true
+	}
+
+	var kept, removed []BookedSeat
+	for _, seat := range b.Seats {
+		if remove[seat.SeatID] {
+			removed = append(removed, seat)
+		} else {
+			kept = append(kept, seat)
+		}
+	}
+
+	b.Seats = kept
+	b.TotalSeats = len(b.Seats)
+	b.UpdateTimestamp()
+	return removed
+}
+
+// CalculatePricing calculates the total pricing for the booking with a pricing rule,
+// recording the rule on the booking
+func (b *Booking) CalculatePricing(rule PricingRule, discount float64) {
 	baseAmount := 0.0
 	for _, seat := range b.Seats {
 		baseAmount += seat.Price
 	}
 	
-	convenienceFee := baseAmount * convenienceFeePercent / 100
+	// A discount never exceeds the tickets it applies to, so tax and total cannot go negative
+	discount = math.Max(0, math.Min(discount, baseAmount))
+	convenienceFee := rule.convenienceFee(baseAmount, len(b.Seats))
 	taxableAmount := baseAmount + convenienceFee - discount
-	tax := taxableAmount * taxPercent / 100
-	totalAmount := baseAmount + convenienceFee + tax - discount
+	taxes, tax := rule.taxes(taxableAmount)
+	totalAmount := math.Max(0, baseAmount+convenienceFee+tax-discount)
 	
 	b.Pricing = BookingPricing{
 		BaseAmount:     baseAmount,
 		ConvenienceFee: convenienceFee,
 		Tax:            tax,
+		Taxes:          taxes,
 		Discount:       discount,
 		TotalAmount:    totalAmount,
 		PaidAmount:     0,
 	}
+	b.PricingRule = rule.Snapshot()
 	b.UpdateTimestamp()
 }
 
+// AppliedPricingRule returns the rule the booking was priced with.
+// Bookings made before pricing rules existed were priced with the default rule.
+func (b *Booking) AppliedPricingRule() PricingRule {
+	if b.PricingRule == nil {
+		return DefaultPricingRule
+	}
+	return b.PricingRule.Rule()
+}
+
 // MarkAsPaid marks the booking as paid
 func (b *Booking) MarkAsPaid(transactionID, paymentMethod string, paidAmount float64) {
 	b.PaymentStatus = "completed"
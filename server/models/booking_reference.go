@@ -0,0 +1,93 @@
+package models
+
+import (
+	"crypto/rand"
+	"strings"
+)
+
+// Booking references look like CF7K3QJ9MZ4: the CF prefix, eight random
+// Crockford base32 symbols (40 bits) and a Crockford check symbol that
+// catches single typos and most transpositions when codes are read out.
+const (
+	BookingReferencePrefix = "CF"
+	bookingReferenceLength = 8
+
+	crockfordAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+	crockfordCheck    = crockfordAlphabet + "*~$=U"
+)
+
+// NewBookingReference generates a random booking reference.
+// Uniqueness is enforced by the unique index on booking_id; callers retry on conflict.
+func NewBookingReference() string {
+	buf := make([]byte, 5) // 40 bits, exactly eight symbols
+	_, _ = rand.Read(buf)
+
+	var value uint64
+	for _, b := range buf {
+		value = value<<8 | uint64(b)
+	}
+
+	symbols := make([]byte, bookingReferenceLength)
+	for i := bookingReferenceLength - 1; i >= 0; i-- {
+		symbols[i] = crockfordAlphabet[value&31]
+		value >>= 5
+	}
+
+	return BookingReferencePrefix + string(symbols) + string(bookingReferenceCheck(string(symbols)))
+}
+
+// NormalizeBookingReference turns a reference as typed or read aloud into its stored form.
+// Case, spaces and hyphens are ignored, and the look-alikes I, L and O are read as 1, 1 and 0.
+func NormalizeBookingReference(ref string) string {
+	ref = strings.ToUpper(strings.TrimSpace(ref))
+	ref = strings.NewReplacer("-", "", " ", "").Replace(ref)
+	if !strings.HasPrefix(ref, BookingReferencePrefix) {
+		return ref
+	}
+
+	body := strings.NewReplacer("I", "1", "L", "1", "O", "0").Replace(ref[len(BookingReferencePrefix):])
+	return BookingReferencePrefix + body
+}
+
+// ValidBookingReference checks the format and check symbol of a normalized reference.
+// Legacy references (CF, a date and six digits) carry no check symbol and are accepted as-is.
+func ValidBookingReference(ref string) bool {
+	if isLegacyBookingReference(ref) {
+		return true
+	}
+
+	if len(ref) != len(BookingReferencePrefix)+bookingReferenceLength+1 || !strings.HasPrefix(ref, BookingReferencePrefix) {
+		return false
+	}
+
+	body := ref[len(BookingReferencePrefix) : len(ref)-1]
+	for i := 0; i < len(body); i++ {
+		if strings.IndexByte(crockfordAlphabet, body[i]) < 0 {
+			return false
+		}
+	}
+
+	return ref[len(ref)-1] == bookingReferenceCheck(body)
+}
+
+// bookingReferenceCheck computes the Crockford mod 37 check symbol
+func bookingReferenceCheck(symbols string) byte {
+	var remainder uint64
+	for i := 0; i < len(symbols); i++ {
+		remainder = (remainder*32 + uint64(strings.IndexByte(crockfordAlphabet, symbols[i]))) % 37
+	}
+	return crockfordCheck[remainder]
+}
+
+// isLegacyBookingReference matches the CF{YYYYMMDD}{6 digits} references issued before
+func isLegacyBookingReference(ref string) bool {
+	if len(ref) != 16 || !strings.HasPrefix(ref, BookingReferencePrefix) {
+		return false
+	}
+	for _, r := range ref[len(BookingReferencePrefix):] {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Refund statuses
+const (
+	RefundPending   = "pending"
+	RefundSucceeded = "succeeded"
+	RefundFailed    = "failed"
+)
+
+// CancellationPolicy decides how much of a paid booking is refunded on cancellation
+type CancellationPolicy struct {
+	FullRefundHours          float64 `bson:"full_refund_hours" json:"full_refund_hours"`                   // Full refund until this many hours before the show
+	PartialRefundPercent     float64 `bson:"partial_refund_percent" json:"partial_refund_percent"`         // Refund after that, until the show starts
+	ConvenienceFeeRefundable bool    `bson:"convenience_fee_refundable" json:"convenience_fee_refundable"` // Whether the convenience fee is returned
+}
+
+// DefaultCancellationPolicy applies to theaters without a policy of their own
+var DefaultCancellationPolicy = CancellationPolicy{
+	FullRefundHours:      24,
+	PartialRefundPercent: 50,
+}
+
+// RefundQuote is the refund a cancellation would produce
+type RefundQuote struct {
+	Percent    float64 `json:"percent"`    // Share of the refundable amount returned
+	Refundable float64 `json:"refundable"` // Paid amount the policy applies to
+	Amount     float64 `json:"amount"`     // Amount returned to the customer
+	Reason     string  `json:"reason"`     // Which policy tier applied
+}
+
+// Refund records money returned for a booking
+type Refund struct {
+	ID         bson.ObjectID `bson:"_id" json:"id"`
+	ProviderID string        `bson:"provider_id,omitempty" json:"provider_id,omitempty"` // Provider refund reference, when returned through a single charge
+	Amount     float64       `bson:"amount" json:"amount"`
+	Percent    float64       `bson:"percent" json:"percent"`
+	Reason     string        `bson:"reason" json:"reason"`
+	Status     string        `bson:"status" json:"status"`                   // pending, succeeded, failed
+	Parts      []RefundPart  `bson:"parts,omitempty" json:"parts,omitempty"` // Share returned through each charge
+	CreatedAt  time.Time     `bson:"created_at" json:"created_at"`
+	UpdatedAt  time.Time     `bson:"updated_at" json:"updated_at"`
+}
+
+// RefundPart is the share of a refund returned through one payment charge
+type RefundPart struct {
+	IntentID   string  `bson:"intent_id" json:"intent_id"`
+	Amount     float64 `bson:"amount" json:"amount"`
+	ProviderID string  `bson:"provider_id,omitempty" json:"provider_id,omitempty"`
+}
+
+// Validate checks that the policy values are within range
+func (p CancellationPolicy) Validate() error {
+	if p.FullRefundHours < 0 {
+		return fmt.Errorf("full_refund_hours must not be negative")
+	}
+	if p.PartialRefundPercent < 0 || p.PartialRefundPercent > 100 {
+		return fmt.Errorf("partial_refund_percent must be between 0 and 100")
+	}
+	return nil
+}
+
+// EffectiveCancellationPolicy returns the theater's policy, or the default when it has none
+func EffectiveCancellationPolicy(theater *Theater) CancellationPolicy {
+	if theater != nil && theater.CancellationPolicy != nil {
+		return *theater.CancellationPolicy
+	}
+	return DefaultCancellationPolicy
+}
+
+// Quote computes the refund for cancelling the booking at the given time.
+// Only paid bookings are refunded, and never more than what is left of the payment.
+func (p CancellationPolicy) Quote(b *Booking, at time.Time) RefundQuote {
+	return p.quote(b, b.Pricing.TotalAmount, b.Pricing.ConvenienceFee, at)
+}
+
+// QuoteSeatRemoval computes the refund for reducing the booking's pricing to remaining
+func (p CancellationPolicy) QuoteSeatRemoval(b *Booking, remaining BookingPricing, at time.Time) RefundQuote {
+	return p.quote(b, b.Pricing.TotalAmount-remaining.TotalAmount, b.Pricing.ConvenienceFee-remaining.ConvenienceFee, at)
+}
+
+// quote applies the policy tier to amount, of which convenienceFee is the fee share
+func (p CancellationPolicy) quote(b *Booking, amount, convenienceFee float64, at time.Time) RefundQuote {
+	if b.PaymentStatus != "completed" && b.PaymentStatus != "partially_refunded" {
+		return RefundQuote{Reason: "not_paid"}
+	}
+
+	refundable := amount
+	if !p.ConvenienceFeeRefundable {
+		refundable -= convenienceFee
+	}
+	if remaining := b.Pricing.PaidAmount - b.RefundedAmount(); refundable > remaining {
+		refundable = remaining
+	}
+	if refundable <= 0 {
+		return RefundQuote{Reason: "nothing_refundable"}
+	}
+
+	quote := RefundQuote{Refundable: roundAmount(refundable)}
+	untilShow := b.ShowTime.Sub(at)
+	switch {
+	case untilShow >= time.Duration(p.FullRefundHours*float64(time.Hour)):
+		quote.Percent = 100
+		quote.Reason = "full_refund"
+	case untilShow > 0:
+		quote.Percent = p.PartialRefundPercent
+		quote.Reason = "partial_refund"
+	default:
+		quote.Reason = "show_started"
+	}
+
+	quote.Amount = roundAmount(refundable * quote.Percent / 100)
+	return quote
+}
+
+// RefundedAmount returns the total of refunds that were not rejected by the provider
+func (b *Booking) RefundedAmount() float64 {
+	total := 0.0
+	for _, refund := range b.Refunds {
+		if refund.Status != RefundFailed {
+			total += refund.Amount
+		}
+	}
+	return total
+}
+
+// PaymentCharges returns the payments captured for the booking. A booking paid before charges
+// were recorded has its payment intent as its only charge.
+func (b *Booking) PaymentCharges() []PaymentCharge {
+	if len(b.Charges) > 0 || b.PaymentIntentID == "" || b.Pricing.PaidAmount <= 0 {
+		return b.Charges
+	}
+	return []PaymentCharge{{
+		IntentID:       b.PaymentIntentID,
+		Amount:         b.Pricing.PaidAmount,
+		RefundedAmount: b.RefundedAmount(),
+	}}
+}
+
+// AllocateRefund splits a refund across the booking's charges, newest first, never taking more
+// from a charge than it has left. It reports false when the charges cannot cover the amount.
+func (b *Booking) AllocateRefund(amount float64) ([]RefundPart, bool) {
+	remaining := math.Round(amount * 100)
+	charges := b.PaymentCharges()
+
+	var parts []RefundPart
+	for i := len(charges) - 1; i >= 0 && remaining > 0; i-- {
+		share := math.Min(math.Round((charges[i].Amount-charges[i].RefundedAmount)*100), remaining)
+		if share <= 0 {
+			continue
+		}
+		parts = append(parts, RefundPart{IntentID: charges[i].IntentID, Amount: share / 100})
+		remaining -= share
+	}
+	return parts, remaining <= 0
+}
+
+// roundAmount rounds to whole cents
+func roundAmount(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
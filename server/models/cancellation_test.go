@@ -0,0 +1,36 @@
+package models
+
+import "testing"
+
+func TestAllocateRefundSplitsAcrossCharges(t *testing.T) {
+	booking := &Booking{Charges: []PaymentCharge{
+		{IntentID: "pi_original", Amount: 40, RefundedAmount: 10},
+		{IntentID: "pi_exchange", Amount: 15},
+	}}
+
+	parts, covered := booking.AllocateRefund(45)
+	if !covered {
+		t.Fatal("45 of the 45 left was not covered")
+	}
+	want := []RefundPart{{IntentID: "pi_exchange", Amount: 15}, {IntentID: "pi_original", Amount: 30}}
+	if len(parts) != len(want) || parts[0] != want[0] || parts[1] != want[1] {
+		t.Fatalf("parts %+v, want %+v", parts, want)
+	}
+
+	if _, covered := booking.AllocateRefund(45.01); covered {
+		t.Fatal("refund larger than what is left was covered")
+	}
+}
+
+func TestAllocateRefundUnrecordedCharge(t *testing.T) {
+	booking := &Booking{
+		PaymentIntentID: "pi_original",
+		Pricing:         BookingPricing{PaidAmount: 30},
+		Refunds:         []Refund{{Amount: 10, Status: RefundSucceeded}, {Amount: 20, Status: RefundFailed}},
+	}
+
+	parts, covered := booking.AllocateRefund(20)
+	if !covered || len(parts) != 1 || parts[0].IntentID != "pi_original" || parts[0].Amount != 20 {
+		t.Fatalf("parts %+v (covered %v), want 20 from pi_original", parts, covered)
+	}
+}
@@ -0,0 +1,131 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Reasons prices are recomputed
+const (
+	RepriceScheduled = "scheduled"
+	RepriceBooking   = "booking"
+)
+
+// DynamicPricingConfig adjusts seat prices of a theater's shows by demand.
+// The multiplier applied to each seat's base price is
+//
+//	1 + OccupancyWeight*(occupancy - TargetOccupancy)
+//	  + LastMinuteAdjustment, scaled by how far into the last-minute window the show is
+//	  + the adjustment for the show's day of week
+//
+// clamped to [FloorMultiplier, CeilingMultiplier].
+type DynamicPricingConfig struct {
+	Enabled              bool               `bson:"enabled" json:"enabled"`
+	FloorMultiplier      float64            `bson:"floor_multiplier" json:"floor_multiplier"`                   // Lowest multiplier, e.g. 0.8
+	CeilingMultiplier    float64            `bson:"ceiling_multiplier" json:"ceiling_multiplier"`               // Highest multiplier, e.g. 1.5
+	TargetOccupancy      float64            `bson:"target_occupancy" json:"target_occupancy"`                   // Occupancy (0-1) at which the occupancy term is zero
+	OccupancyWeight      float64            `bson:"occupancy_weight" json:"occupancy_weight"`                   // Multiplier change per unit of occupancy above or below target
+	LastMinuteHours      float64            `bson:"last_minute_hours" json:"last_minute_hours"`                 // Window before the show the last-minute adjustment ramps up in
+	LastMinuteAdjustment float64            `bson:"last_minute_adjustment" json:"last_minute_adjustment"`       // Reached at show time; negative for last-minute discounts
+	DayAdjustments       map[string]float64 `bson:"day_adjustments,omitempty" json:"day_adjustments,omitempty"` // Day name to adjustment, e.g. {"saturday": 0.1}
+}
+
+// Validate checks that the bounds and weights are sensible
+func (c *DynamicPricingConfig) Validate() error {
+	if c.FloorMultiplier <= 0 || c.CeilingMultiplier <= 0 {
+		return fmt.Errorf("floor_multiplier and ceiling_multiplier must be positive")
+	}
+	if c.FloorMultiplier > 1 || c.CeilingMultiplier < 1 {
+		return fmt.Errorf("the multiplier bounds must include 1")
+	}
+	if c.TargetOccupancy < 0 || c.TargetOccupancy > 1 {
+		return fmt.Errorf("target_occupancy must be between 0 and 1")
+	}
+	if c.LastMinuteHours < 0 {
+		return fmt.Errorf("last_minute_hours cannot be negative")
+	}
+	for day := range c.DayAdjustments {
+		if _, ok := parseWeekday(day); !ok {
+			return fmt.Errorf("unknown day %q in day_adjustments", day)
+		}
+	}
+	return nil
+}
+
+// PricingFactors explains how a multiplier was reached
+type PricingFactors struct {
+	Occupancy           float64 `bson:"occupancy" json:"occupancy"` // Booked share of the seats, 0-1
+	HoursToShow         float64 `bson:"hours_to_show" json:"hours_to_show"`
+	OccupancyAdjustment float64 `bson:"occupancy_adjustment" json:"occupancy_adjustment"`
+	TimeAdjustment      float64 `bson:"time_adjustment" json:"time_adjustment"`
+	DayOfWeek           string  `bson:"day_of_week" json:"day_of_week"`
+	DayAdjustment       float64 `bson:"day_adjustment" json:"day_adjustment"`
+	UnclampedMultiplier float64 `bson:"unclamped_multiplier" json:"unclamped_multiplier"`
+}
+
+// Multiplier computes the price multiplier for a showtime at a theater at the given time
+func (c *DynamicPricingConfig) Multiplier(showtime *Showtime, theater *Theater, now time.Time) (float64, PricingFactors) {
+	factors := PricingFactors{
+		HoursToShow: math.Max(showtime.ShowTime.Sub(now).Hours(), 0),
+	}
+
+	if showtime.TotalSeats > 0 {
+		factors.Occupancy = math.Min(float64(showtime.BookedSeats)/float64(showtime.TotalSeats), 1)
+	}
+	factors.OccupancyAdjustment = c.OccupancyWeight * (factors.Occupancy - c.TargetOccupancy)
+
+	if c.LastMinuteHours > 0 && factors.HoursToShow < c.LastMinuteHours {
+		factors.TimeAdjustment = c.LastMinuteAdjustment * (1 - factors.HoursToShow/c.LastMinuteHours)
+	}
+
+	weekday := theater.LocalTime(showtime.ShowTime).Weekday()
+	factors.DayOfWeek = weekday.String()
+	for day, adjustment := range c.DayAdjustments {
+		if parsed, ok := parseWeekday(day); ok && parsed == weekday {
+			factors.DayAdjustment = adjustment
+		}
+	}
+
+	factors.UnclampedMultiplier = 1 + factors.OccupancyAdjustment + factors.TimeAdjustment + factors.DayAdjustment
+	multiplier := math.Max(c.FloorMultiplier, math.Min(c.CeilingMultiplier, factors.UnclampedMultiplier))
+	return math.Round(multiplier*1000) / 1000, factors
+}
+
+// DynamicSeatPrice applies a multiplier to a base price, in cents
+func DynamicSeatPrice(basePrice, multiplier float64) float64 {
+	return roundAmount(basePrice * multiplier)
+}
+
+// SeatBasePrice returns the price a seat is repriced from.
+// Seats created before dynamic pricing have no base price and use their current price.
+func (s *Seat) SeatBasePrice() float64 {
+	if s.BasePrice > 0 {
+		return s.BasePrice
+	}
+	return s.Price
+}
+
+// PriceChange records a recomputation of a showtime's seat prices
+type PriceChange struct {
+	ID                 bson.ObjectID        `bson:"_id,omitempty" json:"id"`
+	ShowtimeID         bson.ObjectID        `bson:"showtime_id" json:"showtime_id"`
+	TheaterID          bson.ObjectID        `bson:"theater_id" json:"theater_id"`
+	Reason             string               `bson:"reason" json:"reason"` // scheduled, booking
+	PreviousMultiplier float64              `bson:"previous_multiplier" json:"previous_multiplier"`
+	Multiplier         float64              `bson:"multiplier" json:"multiplier"`
+	Factors            PricingFactors       `bson:"factors" json:"factors"`
+	Config             DynamicPricingConfig `bson:"config" json:"config"` // Settings the change was computed with
+	Prices             []SeatPriceChange    `bson:"prices" json:"prices"`
+	CreatedAt          time.Time            `bson:"created_at" json:"created_at"`
+}
+
+// SeatPriceChange is the new price of the available seats sharing a base price
+type SeatPriceChange struct {
+	BasePrice     float64  `bson:"base_price" json:"base_price"`
+	PreviousPrice float64  `bson:"previous_price" json:"previous_price"`
+	Price         float64  `bson:"price" json:"price"`
+	SeatIDs       []string `bson:"seat_ids" json:"seat_ids"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// IdempotencyRecord stores the first response to a request carrying an Idempotency-Key
+type IdempotencyRecord struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	UserID      string        `bson:"user_id" json:"user_id"`           // User who sent the request
+	Key         string        `bson:"key" json:"key"`                   // Client supplied Idempotency-Key
+	Fingerprint string        `bson:"fingerprint" json:"fingerprint"`   // Hash of method, path and body
+	Status      string        `bson:"status" json:"status"`             // processing, completed
+	StatusCode  int           `bson:"status_code" json:"status_code"`   // Stored response status
+	ContentType string        `bson:"content_type" json:"content_type"` // Stored response content type
+	Body        []byte        `bson:"body" json:"-"`                    // Stored response body
+	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
+	ExpiresAt   time.Time     `bson:"expires_at" json:"expires_at"` // Removed by a TTL index after this time
+}
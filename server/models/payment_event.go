@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// PaymentEvent records a payment provider webhook so that every event is applied once
+type PaymentEvent struct {
+	ID          bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	Provider    string        `bson:"provider" json:"provider"`                         // Payment gateway that sent the event
+	EventID     string        `bson:"event_id" json:"event_id"`                         // Provider event ID
+	Type        string        `bson:"type" json:"type"`                                 // payment.succeeded, payment.failed, ...
+	IntentID    string        `bson:"intent_id" json:"intent_id"`                       // Provider payment intent
+	BookingID   string        `bson:"booking_id,omitempty" json:"booking_id,omitempty"` // Affected booking, once resolved
+	Amount      int64         `bson:"amount" json:"amount"`                             // Minor units
+	Status      string        `bson:"status" json:"status"`                             // processing, processed, ignored
+	Outcome     string        `bson:"outcome,omitempty" json:"outcome,omitempty"`       // What the event changed
+	ReceivedAt  time.Time     `bson:"received_at" json:"received_at"`
+	ProcessedAt *time.Time    `bson:"processed_at,omitempty" json:"processed_at,omitempty"`
+}
@@ -0,0 +1,187 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Pricing rule scopes, from least to most specific
+const (
+	PricingScopeDefault = "default"
+	PricingScopeState   = "state"
+	PricingScopeTheater = "theater"
+)
+
+// TaxComponent is one tax levied on a booking, such as a state or a city tax
+type TaxComponent struct {
+	Name    string  `bson:"name" json:"name"`
+	Percent float64 `bson:"percent" json:"percent"`
+}
+
+// PricingRule sets the convenience fee and taxes charged on tickets.
+// A theater rule beats a rule for the theater's state, which beats the default rule.
+type PricingRule struct {
+	ID                      bson.ObjectID  `bson:"_id,omitempty" json:"id,omitempty"`
+	Scope                   string         `bson:"scope" json:"scope"`                               // default, state, theater
+	TheaterID               *bson.ObjectID `bson:"theater_id,omitempty" json:"theater_id,omitempty"` // Set for theater rules
+	State                   string         `bson:"state,omitempty" json:"state,omitempty"`           // Set for state rules, stored upper case
+	ConvenienceFeePercent   float64        `bson:"convenience_fee_percent" json:"convenience_fee_percent"`
+	ConvenienceFeePerTicket float64        `bson:"convenience_fee_per_ticket" json:"convenience_fee_per_ticket"` // Flat fee added for every seat
+	Taxes                   []TaxComponent `bson:"taxes" json:"taxes"`                                           // Levied on tickets plus fee, less discounts
+	UpdatedBy               string         `bson:"updated_by,omitempty" json:"updated_by,omitempty"`
+	CreatedAt               time.Time      `bson:"created_at" json:"created_at"`
+	UpdatedAt               time.Time      `bson:"updated_at" json:"updated_at"`
+}
+
+// DefaultPricingRule applies when no rule was configured: a 2% convenience fee and 18% tax
+var DefaultPricingRule = PricingRule{
+	Scope:                 PricingScopeDefault,
+	ConvenienceFeePercent: 2,
+	Taxes:                 []TaxComponent{{Name: "Tax", Percent: 18}},
+}
+
+// PricingRuleSnapshot records the rule a booking was priced with, so later
+// changes to the rules do not change what the customer agreed to pay
+type PricingRuleSnapshot struct {
+	RuleID                  *bson.ObjectID `bson:"rule_id,omitempty" json:"rule_id,omitempty"` // Empty for the built-in default
+	Scope                   string         `bson:"scope" json:"scope"`
+	ConvenienceFeePercent   float64        `bson:"convenience_fee_percent" json:"convenience_fee_percent"`
+	ConvenienceFeePerTicket float64        `bson:"convenience_fee_per_ticket" json:"convenience_fee_per_ticket"`
+	Taxes                   []TaxComponent `bson:"taxes" json:"taxes"`
+}
+
+// TaxAmount is a tax component charged on a booking
+type TaxAmount struct {
+	Name    string  `bson:"name" json:"name"`
+	Percent float64 `bson:"percent" json:"percent"`
+	Amount  float64 `bson:"amount" json:"amount"`
+}
+
+// NormalizeState returns the form states are stored and matched in
+func NormalizeState(state string) string {
+	return strings.ToUpper(strings.TrimSpace(state))
+}
+
+// Validate checks that the rule is well formed and normalizes its scope key
+func (r *PricingRule) Validate() error {
+	switch r.Scope {
+	case PricingScopeDefault:
+		r.TheaterID = nil
+		r.State = ""
+	case PricingScopeState:
+		r.TheaterID = nil
+		r.State = NormalizeState(r.State)
+		if r.State == "" {
+			return fmt.Errorf("state is required for state rules")
+		}
+	case PricingScopeTheater:
+		r.State = ""
+		if r.TheaterID == nil || r.TheaterID.IsZero() {
+			return fmt.Errorf("theater_id is required for theater rules")
+		}
+	default:
+		return fmt.Errorf("scope must be %q, %q or %q", PricingScopeDefault, PricingScopeState, PricingScopeTheater)
+	}
+
+	if r.ConvenienceFeePercent < 0 || r.ConvenienceFeePercent > 100 {
+		return fmt.Errorf("convenience_fee_percent must be between 0 and 100")
+	}
+	if r.ConvenienceFeePerTicket < 0 {
+		return fmt.Errorf("convenience_fee_per_ticket cannot be negative")
+	}
+	for _, tax := range r.Taxes {
+		if tax.Name == "" {
+			return fmt.Errorf("every tax needs a name")
+		}
+		if tax.Percent < 0 || tax.Percent > 100 {
+			return fmt.Errorf("tax %s must be between 0 and 100 percent", tax.Name)
+		}
+	}
+	return nil
+}
+
+// Snapshot returns the part of the rule stored on a booking
+func (r PricingRule) Snapshot() *PricingRuleSnapshot {
+	snapshot := &PricingRuleSnapshot{
+		Scope:                   r.Scope,
+		ConvenienceFeePercent:   r.ConvenienceFeePercent,
+		ConvenienceFeePerTicket: r.ConvenienceFeePerTicket,
+		Taxes:                   r.Taxes,
+	}
+	if !r.ID.IsZero() {
+		id := r.ID
+		snapshot.RuleID = &id
+	}
+	return snapshot
+}
+
+// Rule returns the rule a snapshot was taken from
+func (s *PricingRuleSnapshot) Rule() PricingRule {
+	rule := PricingRule{
+		Scope:                   s.Scope,
+		ConvenienceFeePercent:   s.ConvenienceFeePercent,
+		ConvenienceFeePerTicket: s.ConvenienceFeePerTicket,
+		Taxes:                   s.Taxes,
+	}
+	if s.RuleID != nil {
+		rule.ID = *s.RuleID
+	}
+	return rule
+}
+
+// convenienceFee returns the fee on an amount covering the given number of tickets
+func (r PricingRule) convenienceFee(baseAmount float64, tickets int) float64 {
+	return baseAmount*r.ConvenienceFeePercent/100 + r.ConvenienceFeePerTicket*float64(tickets)
+}
+
+// taxes returns each tax component levied on a taxable amount, and their sum
+func (r PricingRule) taxes(taxableAmount float64) ([]TaxAmount, float64) {
+	amounts := make([]TaxAmount, 0, len(r.Taxes))
+	total := 0.0
+	for _, tax := range r.Taxes {
+		amount := taxableAmount * tax.Percent / 100
+		amounts = append(amounts, TaxAmount{Name: tax.Name, Percent: tax.Percent, Amount: amount})
+		total += amount
+	}
+	return amounts, total
+}
+
+// PriceRange prices a single ticket with the given base price
+func (r PricingRule) PriceRange(basePrice float64) PriceRange {
+	fee := r.convenienceFee(basePrice, 1)
+	_, tax := r.taxes(basePrice + fee)
+	return PriceRange{
+		BasePrice:      basePrice,
+		ConvenienceFee: fee,
+		Tax:            tax,
+		TotalPrice:     basePrice + fee + tax,
+	}
+}
+
+// ShowPricing prices the seat categories of a show
+func (r PricingRule) ShowPricing(premiumBase, regularBase float64) ShowPricing {
+	return ShowPricing{
+		Premium: r.PriceRange(premiumBase),
+		Regular: r.PriceRange(regularBase),
+	}
+}
+
+// ShowPricingForSeats prices the seat categories of a show from the seats themselves,
+// taking the lowest seat price of each category as its base price
+func (r PricingRule) ShowPricingForSeats(seats []Seat) ShowPricing {
+	return r.ShowPricing(lowestSeatPrice(seats, "premium"), lowestSeatPrice(seats, "regular"))
+}
+
+// lowestSeatPrice returns the lowest price of the seats of a type
+func lowestSeatPrice(seats []Seat, seatType string) float64 {
+	lowest := 0.0
+	for _, seat := range seats {
+		if seat.SeatType == seatType && (lowest == 0 || seat.Price < lowest) {
+			lowest = seat.Price
+		}
+	}
+	return lowest
+}
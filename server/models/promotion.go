@@ -0,0 +1,233 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Promotion discount types
+const (
+	DiscountPercentage = "percentage"
+	DiscountFlat       = "flat"
+)
+
+// ErrPromotionNotApplicable is wrapped by every reason a promo code cannot be used
+var ErrPromotionNotApplicable = errors.New("promo code cannot be applied")
+
+// Promotion represents a promo code and the rules for redeeming it
+type Promotion struct {
+	ID            bson.ObjectID   `bson:"_id,omitempty" json:"id"`
+	Code          string          `bson:"code" json:"code"` // Stored upper case
+	Description   string          `bson:"description" json:"description"`
+	DiscountType  string          `bson:"discount_type" json:"discount_type"`   // percentage, flat
+	DiscountValue float64         `bson:"discount_value" json:"discount_value"` // Percent off or amount off
+	MaxDiscount   float64         `bson:"max_discount" json:"max_discount"`     // Caps percentage discounts; 0 for no cap
+	MinSeats      int             `bson:"min_seats" json:"min_seats"`
+	MinAmount     float64         `bson:"min_amount" json:"min_amount"`         // Minimum ticket amount before fees
+	UsageLimit    int             `bson:"usage_limit" json:"usage_limit"`       // Total redemptions; 0 for unlimited
+	PerUserLimit  int             `bson:"per_user_limit" json:"per_user_limit"` // Redemptions per user; 0 for unlimited
+	UsedCount     int             `bson:"used_count" json:"used_count"`         // Redemptions held by active bookings
+	ValidFrom     time.Time       `bson:"valid_from" json:"valid_from"`
+	ValidUntil    time.Time       `bson:"valid_until" json:"valid_until"`
+	MovieIDs      []int           `bson:"movie_ids,omitempty" json:"movie_ids,omitempty"`     // Empty for every movie
+	TheaterIDs    []bson.ObjectID `bson:"theater_ids,omitempty" json:"theater_ids,omitempty"` // Empty for every theater
+	Formats       []string        `bson:"formats,omitempty" json:"formats,omitempty"`         // Empty for every format
+	IsActive      bool            `bson:"is_active" json:"is_active"`
+	CreatedBy     string          `bson:"created_by" json:"created_by"`
+	CreatedAt     time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt     time.Time       `bson:"updated_at" json:"updated_at"`
+}
+
+// AppliedPromotion records the promo code redeemed by a booking
+type AppliedPromotion struct {
+	PromotionID bson.ObjectID `bson:"promotion_id" json:"promotion_id"`
+	Code        string        `bson:"code" json:"code"`
+	Discount    float64       `bson:"discount" json:"discount"`
+}
+
+// NormalizePromoCode returns the stored form of a promo code
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Validate checks that the promotion is well formed
+func (p *Promotion) Validate() error {
+	if p.Code == "" {
+		return fmt.Errorf("code is required")
+	}
+	switch p.DiscountType {
+	case DiscountPercentage:
+		if p.DiscountValue <= 0 || p.DiscountValue > 100 {
+			return fmt.Errorf("percentage discount must be between 0 and 100")
+		}
+	case DiscountFlat:
+		if p.DiscountValue <= 0 {
+			return fmt.Errorf("flat discount must be positive")
+		}
+	default:
+		return fmt.Errorf("discount_type must be %q or %q", DiscountPercentage, DiscountFlat)
+	}
+	if p.MaxDiscount < 0 || p.MinAmount < 0 || p.MinSeats < 0 || p.UsageLimit < 0 || p.PerUserLimit < 0 {
+		return fmt.Errorf("limits cannot be negative")
+	}
+	if p.ValidUntil.IsZero() || !p.ValidUntil.After(p.ValidFrom) {
+		return fmt.Errorf("valid_until must be after valid_from")
+	}
+	return nil
+}
+
+// CheckEligibility checks whether the promotion can be applied to a booking for a showtime.
+// The booking's seats must already be added. Usage limits are checked by the caller,
+// which has to count redemptions inside the booking transaction.
+func (p *Promotion) CheckEligibility(booking *Booking, showtime *Showtime, now time.Time) error {
+	if !p.IsActive {
+		return fmt.Errorf("%w: promo code is not active", ErrPromotionNotApplicable)
+	}
+	if now.Before(p.ValidFrom) {
+		return fmt.Errorf("%w: promo code is not valid yet", ErrPromotionNotApplicable)
+	}
+	if !now.Before(p.ValidUntil) {
+		return fmt.Errorf("%w: promo code has expired", ErrPromotionNotApplicable)
+	}
+	if p.UsageLimit > 0 && p.UsedCount >= p.UsageLimit {
+		return fmt.Errorf("%w: promo code has been fully redeemed", ErrPromotionNotApplicable)
+	}
+
+	return p.AppliesTo(booking, showtime)
+}
+
+// AppliesTo checks the promotion's movie, theater, format and minimum purchase restrictions
+// against a booking for a showtime. The booking's seats must already be added.
+func (p *Promotion) AppliesTo(booking *Booking, showtime *Showtime) error {
+	if len(p.MovieIDs) > 0 && !containsInt(p.MovieIDs, showtime.MovieID) {
+		return fmt.Errorf("%w: promo code is not valid for this movie", ErrPromotionNotApplicable)
+	}
+	if len(p.TheaterIDs) > 0 && !containsObjectID(p.TheaterIDs, showtime.TheaterID) {
+		return fmt.Errorf("%w: promo code is not valid at this theater", ErrPromotionNotApplicable)
+	}
+	if len(p.Formats) > 0 && !containsFold(p.Formats, showtime.Format) {
+		return fmt.Errorf("%w: promo code is not valid for %s shows", ErrPromotionNotApplicable, showtime.Format)
+	}
+
+	if p.MinSeats > 0 && len(booking.Seats) < p.MinSeats {
+		return fmt.Errorf("%w: promo code requires at least %d seats", ErrPromotionNotApplicable, p.MinSeats)
+	}
+	if p.MinAmount > 0 && seatsAmount(booking.Seats) < p.MinAmount {
+		return fmt.Errorf("%w: promo code requires a minimum ticket amount of %.2f", ErrPromotionNotApplicable, p.MinAmount)
+	}
+
+	return nil
+}
+
+// DiscountFor returns the discount on a ticket amount, never more than the amount itself
+func (p *Promotion) DiscountFor(baseAmount float64) float64 {
+	discount := p.DiscountValue
+	if p.DiscountType == DiscountPercentage {
+		discount = baseAmount * p.DiscountValue / 100
+		if p.MaxDiscount > 0 {
+			discount = math.Min(discount, p.MaxDiscount)
+		}
+	}
+	return roundAmount(math.Min(discount, baseAmount))
+}
+
+// ApplyPromotion reprices the booking with the promotion's discount and records the redemption.
+// The booking keeps the pricing rule it was already priced with.
+func (b *Booking) ApplyPromotion(p *Promotion) {
+	discount := p.DiscountFor(seatsAmount(b.Seats))
+	b.Promotion = &AppliedPromotion{
+		PromotionID: p.ID,
+		Code:        p.Code,
+		Discount:    discount,
+	}
+	b.CalculatePricing(b.AppliedPricingRule(), discount)
+}
+
+// TransferPromotion moves the promo code of an exchanged booking onto this replacement booking,
+// repricing it for the new seats. It reports false, leaving the booking undiscounted, when the
+// promotion does not apply to the new showtime or seats. A deleted promotion keeps its original
+// discount, up to the new ticket amount.
+func (b *Booking) TransferPromotion(applied *AppliedPromotion, p *Promotion, showtime *Showtime) bool {
+	baseAmount := seatsAmount(b.Seats)
+	discount := math.Min(applied.Discount, baseAmount)
+	if p != nil {
+		if p.AppliesTo(b, showtime) != nil {
+			b.Promotion = nil
+			b.CalculatePricing(b.AppliedPricingRule(), 0)
+			return false
+		}
+		discount = p.DiscountFor(baseAmount)
+	}
+
+	b.Promotion = &AppliedPromotion{
+		PromotionID: applied.PromotionID,
+		Code:        applied.Code,
+		Discount:    discount,
+	}
+	b.CalculatePricing(b.AppliedPricingRule(), discount)
+	return true
+}
+
+// RemainingPromotionDiscount returns the promo discount a booking keeps once seats were removed,
+// priced on the remaining seats. It reports false when they no longer meet the promotion's
+// minimum seats or amount. A deleted promotion has its original discount prorated.
+func (b *Booking) RemainingPromotionDiscount(p *Promotion, previousBaseAmount float64) (float64, bool) {
+	if b.Promotion == nil {
+		return 0, true
+	}
+
+	baseAmount := seatsAmount(b.Seats)
+	if p == nil {
+		if previousBaseAmount <= 0 {
+			return 0, true
+		}
+		return roundAmount(math.Min(b.Promotion.Discount*baseAmount/previousBaseAmount, baseAmount)), true
+	}
+
+	if (p.MinSeats > 0 && len(b.Seats) < p.MinSeats) || (p.MinAmount > 0 && baseAmount < p.MinAmount) {
+		return 0, false
+	}
+	// Edits to the promotion after booking never raise the discount
+	return math.Min(p.DiscountFor(baseAmount), b.Promotion.Discount), true
+}
+
+// seatsAmount sums the price of the seats
+func seatsAmount(seats []BookedSeat) float64 {
+	total := 0.0
+	for _, seat := range seats {
+		total += seat.Price
+	}
+	return total
+}
+
+func containsInt(values []int, value int) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsObjectID(values []bson.ObjectID, value bson.ObjectID) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,125 @@
+package models
+
+import (
+	"fmt"
+	"testing"
+)
+
+// promotedBooking creates a booking for count seats at price with the promotion applied
+func promotedBooking(count int, price float64, p *Promotion) *Booking {
+	booking := &Booking{}
+	for i := 1; i <= count; i++ {
+		booking.Seats = append(booking.Seats, BookedSeat{SeatID: fmt.Sprintf("A%d", i), RowID: "A", SeatNumber: i, Price: price})
+	}
+	booking.CalculatePricing(DefaultPricingRule, 0)
+	booking.ApplyPromotion(p)
+	return booking
+}
+
+// removeSeats drops seats from the booking and reprices it as seat cancellation does
+func removeSeats(b *Booking, p *Promotion, seatIDs ...string) bool {
+	previous := b.Pricing
+	b.RemoveSeats(seatIDs)
+	discount, eligible := b.RemainingPromotionDiscount(p, previous.BaseAmount)
+	if eligible {
+		b.Promotion.Discount = discount
+	} else {
+		b.Promotion = nil
+	}
+	b.CalculatePricing(b.AppliedPricingRule(), discount)
+	return eligible
+}
+
+func TestRemainingPromotionDiscountBelowMinSeats(t *testing.T) {
+	promotion := &Promotion{Code: "GROUP5", DiscountType: DiscountFlat, DiscountValue: 60, MinSeats: 5}
+	booking := promotedBooking(5, 20, promotion)
+	if booking.Pricing.Discount != 60 {
+		t.Fatalf("discount %v, want 60", booking.Pricing.Discount)
+	}
+
+	if removeSeats(booking, promotion, "A2", "A3", "A4", "A5") {
+		t.Fatal("one seat is still eligible for a five-seat promotion")
+	}
+	if booking.Pricing.Discount != 0 || booking.Promotion != nil {
+		t.Fatalf("discount %v, promotion %v, want none", booking.Pricing.Discount, booking.Promotion)
+	}
+	if booking.Pricing.TotalAmount <= 0 {
+		t.Fatalf("total %v, want the full price of the remaining seat", booking.Pricing.TotalAmount)
+	}
+}
+
+func TestRemainingPromotionDiscountPercentage(t *testing.T) {
+	promotion := &Promotion{Code: "TENOFF", DiscountType: DiscountPercentage, DiscountValue: 10}
+	booking := promotedBooking(4, 20, promotion)
+
+	if !removeSeats(booking, promotion, "A4") {
+		t.Fatal("promotion without minimums stopped applying")
+	}
+	if booking.Pricing.Discount != 6 || booking.Promotion.Discount != 6 {
+		t.Fatalf("discount %v (recorded %v), want 6", booking.Pricing.Discount, booking.Promotion.Discount)
+	}
+}
+
+func TestRemainingPromotionDiscountDeletedPromotion(t *testing.T) {
+	promotion := &Promotion{Code: "GROUP5", DiscountType: DiscountFlat, DiscountValue: 60, MinSeats: 5}
+	booking := promotedBooking(5, 20, promotion)
+
+	if !removeSeats(booking, nil, "A2", "A3", "A4", "A5") {
+		t.Fatal("deleted promotion stopped applying")
+	}
+	if booking.Pricing.Discount != 12 {
+		t.Fatalf("discount %v, want the prorated 12", booking.Pricing.Discount)
+	}
+}
+
+func TestCalculatePricingClampsDiscount(t *testing.T) {
+	booking := &Booking{Seats: []BookedSeat{{SeatID: "A1", Price: 20}}}
+	booking.CalculatePricing(DefaultPricingRule, 60)
+
+	if booking.Pricing.Discount != 20 {
+		t.Fatalf("discount %v, want it capped at the 20 base amount", booking.Pricing.Discount)
+	}
+	if booking.Pricing.Tax < 0 || booking.Pricing.TotalAmount < 0 {
+		t.Fatalf("tax %v, total %v, want neither negative", booking.Pricing.Tax, booking.Pricing.TotalAmount)
+	}
+}
+
+func TestTransferPromotionIneligibleFormat(t *testing.T) {
+	promotion := &Promotion{Code: "IMAX10", DiscountType: DiscountFlat, DiscountValue: 10, Formats: []string{"IMAX"}}
+	original := promotedBooking(2, 20, promotion)
+	replacement := promotedBooking(2, 20, &Promotion{})
+	replacement.Promotion = nil
+
+	if replacement.TransferPromotion(original.Promotion, promotion, &Showtime{Format: "2D"}) {
+		t.Fatal("IMAX promotion moved to a 2D show")
+	}
+	if replacement.Promotion != nil || replacement.Pricing.Discount != 0 {
+		t.Fatalf("discount %v, promotion %v, want none", replacement.Pricing.Discount, replacement.Promotion)
+	}
+}
+
+func TestTransferPromotionRepricesDiscount(t *testing.T) {
+	promotion := &Promotion{Code: "TENOFF", DiscountType: DiscountPercentage, DiscountValue: 10}
+	original := promotedBooking(2, 20, promotion)
+	replacement := promotedBooking(3, 20, &Promotion{})
+
+	if !replacement.TransferPromotion(original.Promotion, promotion, &Showtime{}) {
+		t.Fatal("promotion without restrictions stopped applying")
+	}
+	if replacement.Pricing.Discount != 6 || replacement.Promotion.Discount != 6 {
+		t.Fatalf("discount %v (recorded %v), want 6", replacement.Pricing.Discount, replacement.Promotion.Discount)
+	}
+}
+
+func TestTransferPromotionDeletedPromotion(t *testing.T) {
+	promotion := &Promotion{Code: "FLAT30", DiscountType: DiscountFlat, DiscountValue: 30}
+	original := promotedBooking(2, 20, promotion)
+	replacement := promotedBooking(1, 20, &Promotion{})
+
+	if !replacement.TransferPromotion(original.Promotion, nil, &Showtime{}) {
+		t.Fatal("deleted promotion stopped applying")
+	}
+	if replacement.Pricing.Discount != 20 || replacement.Promotion.Discount != 20 {
+		t.Fatalf("discount %v (recorded %v), want it capped at 20", replacement.Pricing.Discount, replacement.Promotion.Discount)
+	}
+}
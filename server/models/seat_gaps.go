@@ -0,0 +1,140 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxGapSuggestions is the most alternative selections offered for a rejected one
+const maxGapSuggestions = 3
+
+// SeatGapPolicy stops selections that leave a single empty seat nobody will buy
+type SeatGapPolicy struct {
+	Enabled       bool `bson:"enabled" json:"enabled"`
+	AllowEdgeGaps bool `bson:"allow_edge_gaps" json:"allow_edge_gaps"` // Allow a lone seat at the end of a row
+}
+
+// DefaultSeatGapPolicy rejects lone seats anywhere in a row
+var DefaultSeatGapPolicy = SeatGapPolicy{Enabled: true}
+
+// SeatGapError lists the seats a selection would leave stranded, and selections of the
+// same size that would not
+type SeatGapError struct {
+	SeatIDs     []string   `json:"seat_ids"`
+	Suggestions [][]string `json:"suggestions"`
+}
+
+func (e *SeatGapError) Error() string {
+	return fmt.Sprintf("selection would leave single empty seats: %s", strings.Join(e.SeatIDs, ", "))
+}
+
+// EffectiveSeatGapPolicy returns the theater's policy, or the default one
+func (t *Theater) EffectiveSeatGapPolicy() SeatGapPolicy {
+	if t.SeatGapPolicy == nil {
+		return DefaultSeatGapPolicy
+	}
+	return *t.SeatGapPolicy
+}
+
+// CheckSeatGaps returns a *SeatGapError when taking seatIDs would leave a lone available
+// seat between unavailable seats or the row edge. Seats that were already alone do not count,
+// and a selection is allowed when no selection of the same size avoids a gap, so a nearly
+// full show can still sell out.
+func (s *Showtime) CheckSeatGaps(seatIDs []string, policy SeatGapPolicy) error {
+	if !policy.Enabled || len(seatIDs) == 0 {
+		return nil
+	}
+
+	rows, widths := s.seatRows()
+	selected := make(map[string]bool, len(seatIDs))
+	for _, seatID := range seatIDs {
+		selected[seatID] = true
+	}
+
+	stranded := strandedSeats(rows, selected, policy)
+	if len(stranded) == 0 {
+		return nil
+	}
+
+	// Offer blocks of the same size and seat type that leave no new gaps, most central first
+	seatType := ""
+	if seat := s.GetSeatByID(seatIDs[0]); seat != nil {
+		seatType = seat.SeatType
+	}
+	size := len(UniqueSeatIDs(seatIDs))
+
+	type candidate struct {
+		seatIDs []string
+		score   float64
+	}
+	var candidates []candidate
+	for _, block := range availableBlocks(rows, seatType, nil) {
+		for start := 0; start+size <= len(block.seats); start++ {
+			window := seatBlock{rowIndex: block.rowIndex, seats: block.seats[start : start+size]}
+			if window.splitsLoveseat() {
+				continue
+			}
+			ids := make([]string, 0, size)
+			windowSeats := make(map[string]bool, size)
+			for _, seat := range window.seats {
+				ids = append(ids, seat.SeatID)
+				windowSeats[seat.SeatID] = true
+			}
+			if len(strandedSeats(rows, windowSeats, policy)) == 0 {
+				candidates = append(candidates, candidate{seatIDs: ids, score: window.score(len(rows), widths[block.rowIndex])})
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score < candidates[j].score })
+	gapErr := &SeatGapError{SeatIDs: stranded}
+	for i := 0; i < len(candidates) && i < maxGapSuggestions; i++ {
+		gapErr.Suggestions = append(gapErr.Suggestions, candidates[i].seatIDs)
+	}
+	return gapErr
+}
+
+// strandedSeats returns the seats that become the only available seat between two walls
+// once the selected seats are taken. Unavailable seats, aisles, missing seats and the
+// row ends all count as walls. Wheelchair spaces and companion seats are never stranded.
+func strandedSeats(rows [][]Seat, selected map[string]bool, policy SeatGapPolicy) []string {
+	var stranded []string
+	for _, row := range rows {
+		touched := false
+		for _, seat := range row {
+			touched = touched || selected[seat.SeatID]
+		}
+		if !touched {
+			continue
+		}
+
+		for i, seat := range row {
+			if seat.Status != SeatAvailable || selected[seat.SeatID] || seat.IsAccessible() {
+				continue
+			}
+
+			leftEdge := i == 0 || row[i-1].GridColumn() != seat.GridColumn()-1
+			rightEdge := i == len(row)-1 || row[i+1].GridColumn() != seat.GridColumn()+1
+			if policy.AllowEdgeGaps && (leftEdge || rightEdge) {
+				continue
+			}
+
+			leftOpen := !leftEdge && row[i-1].Status == SeatAvailable
+			rightOpen := !rightEdge && row[i+1].Status == SeatAvailable
+			if !leftOpen && !rightOpen {
+				continue // Already alone before this selection
+			}
+
+			leftTaken := leftEdge || row[i-1].Status != SeatAvailable || selected[row[i-1].SeatID]
+			rightTaken := rightEdge || row[i+1].Status != SeatAvailable || selected[row[i+1].SeatID]
+			if leftTaken && rightTaken {
+				stranded = append(stranded, seat.SeatID)
+			}
+		}
+	}
+	return stranded
+}
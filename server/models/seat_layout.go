@@ -0,0 +1,232 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Seat kinds
+const (
+	SeatKindStandard   = "standard"
+	SeatKindWheelchair = "wheelchair" // Space for a wheelchair, no fixed seat
+	SeatKindCompanion  = "companion"  // Next to a wheelchair space, for the wheelchair user's companion
+	SeatKindLoveseat   = "loveseat"   // Couple seat, sold together with the seat it is paired with
+)
+
+// Seat grid cell types
+const (
+	GridCellSeat  = "seat"
+	GridCellAisle = "aisle" // Column without seats in any row
+	GridCellSpace = "space" // No seat in this row only
+)
+
+// SeatSpec describes one seat of a row with an explicit layout
+type SeatSpec struct {
+	Label      string `bson:"label,omitempty" json:"label,omitempty"`             // Printed on the seat, defaults to its position in the row
+	Column     int    `bson:"column,omitempty" json:"column,omitempty"`           // Grid column from 1, defaults to the column after the previous seat
+	Kind       string `bson:"kind,omitempty" json:"kind,omitempty"`               // standard, wheelchair, companion, loveseat
+	PairedWith string `bson:"paired_with,omitempty" json:"paired_with,omitempty"` // Label of the other half of a loveseat
+}
+
+// seatSpecs returns the seats of a row with every default filled in.
+// Rows without explicit seats get SeatCount numbered seats, skipping Missing
+// numbers and leaving an empty column after each number in AisleAfter.
+func (r SeatRow) seatSpecs() []SeatSpec {
+	if len(r.Seats) > 0 {
+		specs := make([]SeatSpec, len(r.Seats))
+		column := r.Offset
+		for i, spec := range r.Seats {
+			if spec.Label == "" {
+				spec.Label = strconv.Itoa(i + 1)
+			}
+			if spec.Column == 0 {
+				spec.Column = column + 1
+			}
+			if spec.Kind == "" {
+				spec.Kind = SeatKindStandard
+			}
+			column = spec.Column
+			specs[i] = spec
+		}
+		return specs
+	}
+
+	missing := make(map[int]bool, len(r.Missing))
+	for _, number := range r.Missing {
+		missing[number] = true
+	}
+	aisles := make(map[int]bool, len(r.AisleAfter))
+	for _, number := range r.AisleAfter {
+		aisles[number] = true
+	}
+
+	var specs []SeatSpec
+	column := r.Offset
+	for number := 1; number <= r.SeatCount; number++ {
+		column++
+		if !missing[number] {
+			specs = append(specs, SeatSpec{Label: strconv.Itoa(number), Column: column, Kind: SeatKindStandard})
+		}
+		if aisles[number] {
+			column++
+		}
+	}
+	return specs
+}
+
+// Validate checks that every row's seats have unique labels, increasing columns,
+// known kinds and loveseat partners that exist and point back
+func (l SeatLayout) Validate() error {
+	rowIDs := make(map[string]bool, len(l.Rows))
+	for _, row := range l.Rows {
+		if row.RowID == "" {
+			return fmt.Errorf("every row needs a row_id")
+		}
+		if rowIDs[row.RowID] {
+			return fmt.Errorf("row %s appears twice", row.RowID)
+		}
+		rowIDs[row.RowID] = true
+		if row.Offset < 0 {
+			return fmt.Errorf("row %s: offset cannot be negative", row.RowID)
+		}
+
+		specs := row.seatSpecs()
+		byLabel := make(map[string]SeatSpec, len(specs))
+		previous := 0
+		for _, spec := range specs {
+			if _, ok := byLabel[spec.Label]; ok {
+				return fmt.Errorf("row %s: seat %s appears twice", row.RowID, spec.Label)
+			}
+			byLabel[spec.Label] = spec
+			if spec.Column <= previous {
+				return fmt.Errorf("row %s: seat %s must be in a later column than the seat before it", row.RowID, spec.Label)
+			}
+			previous = spec.Column
+
+			switch spec.Kind {
+			case SeatKindStandard, SeatKindWheelchair, SeatKindCompanion, SeatKindLoveseat:
+			default:
+				return fmt.Errorf("row %s: seat %s has unknown kind %q", row.RowID, spec.Label, spec.Kind)
+			}
+		}
+
+		for _, spec := range specs {
+			if spec.Kind != SeatKindLoveseat {
+				continue
+			}
+			partner, ok := byLabel[spec.PairedWith]
+			if !ok || partner.Kind != SeatKindLoveseat || partner.PairedWith != spec.Label {
+				return fmt.Errorf("row %s: loveseat %s must be paired with another loveseat in the row", row.RowID, spec.Label)
+			}
+		}
+	}
+	return nil
+}
+
+// SeatCount returns the number of seats in the layout
+func (l SeatLayout) SeatCount() int {
+	count := 0
+	for _, row := range l.Rows {
+		count += len(row.seatSpecs())
+	}
+	return count
+}
+
+// GridColumn returns the seat's column in the seat grid.
+// Seats created before grid layouts use their seat number.
+func (s Seat) GridColumn() int {
+	if s.Column > 0 {
+		return s.Column
+	}
+	return s.SeatNumber
+}
+
+// IsAccessible reports whether the seat is kept for wheelchair users and their companions
+func (s Seat) IsAccessible() bool {
+	return s.Kind == SeatKindWheelchair || s.Kind == SeatKindCompanion
+}
+
+// UnpairedLoveseats returns the loveseats among seatIDs whose partner is not also selected
+func (s *Showtime) UnpairedLoveseats(seatIDs []string) []string {
+	selected := make(map[string]bool, len(seatIDs))
+	for _, seatID := range seatIDs {
+		selected[seatID] = true
+	}
+
+	var unpaired []string
+	for _, seatID := range UniqueSeatIDs(seatIDs) {
+		seat := s.GetSeatByID(seatID)
+		if seat != nil && seat.PairedWith != "" && !selected[seat.PairedWith] {
+			unpaired = append(unpaired, seatID)
+		}
+	}
+	return unpaired
+}
+
+// SeatGrid lays the seats of a show out as the client draws them
+type SeatGrid struct {
+	Columns int           `json:"columns"`
+	Rows    []SeatGridRow `json:"rows"`
+}
+
+// SeatGridRow is one row of the seat grid
+type SeatGridRow struct {
+	RowID   string         `json:"row_id"`
+	RowType string         `json:"row_type"`
+	Cells   []SeatGridCell `json:"cells"`
+}
+
+// SeatGridCell is one column of a grid row
+type SeatGridCell struct {
+	Column int    `json:"column"`
+	Type   string `json:"type"` // seat, aisle, space
+	Seat   *Seat  `json:"seat,omitempty"`
+}
+
+// BuildSeatGrid arranges seats in rows and columns, in the order their rows were laid out.
+// Columns empty in every row are aisles; other empty cells are spaces.
+func BuildSeatGrid(seats []Seat) SeatGrid {
+	var grid SeatGrid
+	index := make(map[string]int)
+	rowSeats := make(map[string][]Seat)
+	used := make(map[int]bool)
+
+	for _, seat := range seats {
+		if _, ok := index[seat.RowID]; !ok {
+			index[seat.RowID] = len(grid.Rows)
+			grid.Rows = append(grid.Rows, SeatGridRow{RowID: seat.RowID, RowType: seat.SeatType})
+		}
+		rowSeats[seat.RowID] = append(rowSeats[seat.RowID], seat)
+		used[seat.GridColumn()] = true
+		if seat.GridColumn() > grid.Columns {
+			grid.Columns = seat.GridColumn()
+		}
+	}
+
+	for i := range grid.Rows {
+		row := &grid.Rows[i]
+		byColumn := make(map[int]Seat, len(rowSeats[row.RowID]))
+		for _, seat := range rowSeats[row.RowID] {
+			byColumn[seat.GridColumn()] = seat
+		}
+
+		row.Cells = make([]SeatGridCell, 0, grid.Columns)
+		for column := 1; column <= grid.Columns; column++ {
+			cell := SeatGridCell{Column: column, Type: GridCellSpace}
+			if seat, ok := byColumn[column]; ok {
+				cell.Type = GridCellSeat
+				cell.Seat = &seat
+			} else if !used[column] {
+				cell.Type = GridCellAisle
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+	}
+	return grid
+}
+
+// sortByColumn orders the seats of a row from left to right
+func sortByColumn(row []Seat) {
+	sort.Slice(row, func(a, b int) bool { return row[a].GridColumn() < row[b].GridColumn() })
+}
@@ -0,0 +1,215 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Time-of-day price bands
+const (
+	BandMorning   = "morning"
+	BandAfternoon = "afternoon"
+	BandEvening   = "evening"
+	BandNight     = "night"
+)
+
+const holidayDateLayout = "2006-01-02"
+
+// PricingSchedule decides which price band a show falls in, in the theater's local time
+type PricingSchedule struct {
+	AfternoonStart string   `bson:"afternoon_start" json:"afternoon_start"`               // HH:MM, defaults to 12:00
+	EveningStart   string   `bson:"evening_start" json:"evening_start"`                   // HH:MM, defaults to 18:00
+	NightStart     string   `bson:"night_start" json:"night_start"`                       // HH:MM, defaults to 21:00
+	WeekendDays    []string `bson:"weekend_days,omitempty" json:"weekend_days,omitempty"` // Day names, defaults to saturday and sunday
+	Holidays       []string `bson:"holidays,omitempty" json:"holidays,omitempty"`         // YYYY-MM-DD dates priced like weekends
+}
+
+// DefaultPricingSchedule matches the bands documented on Price
+var DefaultPricingSchedule = PricingSchedule{
+	AfternoonStart: "12:00",
+	EveningStart:   "18:00",
+	NightStart:     "21:00",
+	WeekendDays:    []string{"saturday", "sunday"},
+}
+
+// Validate checks the band boundaries, weekend days and holidays
+func (s PricingSchedule) Validate() error {
+	bounds := []struct {
+		name     string
+		value    string
+		fallback string
+	}{
+		{"afternoon_start", s.AfternoonStart, DefaultPricingSchedule.AfternoonStart},
+		{"evening_start", s.EveningStart, DefaultPricingSchedule.EveningStart},
+		{"night_start", s.NightStart, DefaultPricingSchedule.NightStart},
+	}
+	previous := -1
+	for _, bound := range bounds {
+		if bound.value != "" {
+			if _, err := parseClock(bound.value); err != nil {
+				return fmt.Errorf("%s: %v", bound.name, err)
+			}
+		}
+		// Unset boundaries keep their default, which must still fit between the others
+		minutes := s.boundary(bound.value, bound.fallback)
+		if minutes <= previous {
+			return fmt.Errorf("%s must be later than the band before it", bound.name)
+		}
+		previous = minutes
+	}
+
+	for _, day := range s.WeekendDays {
+		if _, ok := parseWeekday(day); !ok {
+			return fmt.Errorf("unknown weekend day %q", day)
+		}
+	}
+	for _, holiday := range s.Holidays {
+		if _, err := time.Parse(holidayDateLayout, holiday); err != nil {
+			return fmt.Errorf("holiday %q must be a YYYY-MM-DD date", holiday)
+		}
+	}
+	return nil
+}
+
+// Band returns the price band of a local start time
+func (s PricingSchedule) Band(local time.Time) string {
+	minutes := local.Hour()*60 + local.Minute()
+	switch {
+	case minutes >= s.boundary(s.NightStart, DefaultPricingSchedule.NightStart):
+		return BandNight
+	case minutes >= s.boundary(s.EveningStart, DefaultPricingSchedule.EveningStart):
+		return BandEvening
+	case minutes >= s.boundary(s.AfternoonStart, DefaultPricingSchedule.AfternoonStart):
+		return BandAfternoon
+	default:
+		return BandMorning
+	}
+}
+
+// IsWeekendOrHoliday reports whether a local date is priced at weekend rates
+func (s PricingSchedule) IsWeekendOrHoliday(local time.Time) bool {
+	date := local.Format(holidayDateLayout)
+	for _, holiday := range s.Holidays {
+		if holiday == date {
+			return true
+		}
+	}
+
+	days := s.WeekendDays
+	if len(days) == 0 {
+		days = DefaultPricingSchedule.WeekendDays
+	}
+	for _, day := range days {
+		if weekday, ok := parseWeekday(day); ok && weekday == local.Weekday() {
+			return true
+		}
+	}
+	return false
+}
+
+// boundary returns a band boundary in minutes after midnight
+func (s PricingSchedule) boundary(value, fallback string) int {
+	if minutes, err := parseClock(value); err == nil {
+		return minutes
+	}
+	minutes, _ := parseClock(fallback)
+	return minutes
+}
+
+// ForBand returns the price of a band. Bands without a price fall back to the
+// nearest priced band, so layouts that only set some bands still price every show.
+func (p Price) ForBand(band string) float64 {
+	order := map[string][]float64{
+		BandMorning:   {p.Morning, p.Afternoon, p.Evening, p.Night},
+		BandAfternoon: {p.Afternoon, p.Evening, p.Morning, p.Night},
+		BandEvening:   {p.Evening, p.Afternoon, p.Night, p.Morning},
+		BandNight:     {p.Night, p.Evening, p.Afternoon, p.Morning},
+	}
+	for _, price := range order[band] {
+		if price > 0 {
+			return price
+		}
+	}
+	return 0
+}
+
+// EffectivePricingSchedule returns the theater's schedule, or the default one
+func (t *Theater) EffectivePricingSchedule() PricingSchedule {
+	if t.PricingSchedule == nil {
+		return DefaultPricingSchedule
+	}
+	return *t.PricingSchedule
+}
+
+// LocalTime converts a time to the theater's time zone, falling back to the server's.
+// A nil theater, such as one that can no longer be found, uses the server's time zone.
+func (t *Theater) LocalTime(at time.Time) time.Time {
+	if t != nil && t.Timezone != "" {
+		if location, err := time.LoadLocation(t.Timezone); err == nil {
+			return at.In(location)
+		}
+	}
+	return at.Local()
+}
+
+// SeatPrice returns the price of a seat in a row for a show starting at showTime
+func (t *Theater) SeatPrice(row SeatRow, showTime time.Time) float64 {
+	schedule := t.EffectivePricingSchedule()
+	local := t.LocalTime(showTime)
+
+	prices := row.Price
+	if row.WeekendPrice != nil && schedule.IsWeekendOrHoliday(local) {
+		prices = *row.WeekendPrice
+	}
+	return prices.ForBand(schedule.Band(local))
+}
+
+// InitializeSeats creates the seats of a screen for a show starting at showTime,
+// laid out on the screen's grid and priced for the show's time band
+func (t *Theater) InitializeSeats(screen *Screen, showTime time.Time) []Seat {
+	var seats []Seat
+
+	for _, row := range screen.SeatLayout.Rows {
+		price := t.SeatPrice(row, showTime)
+		for i, spec := range row.seatSpecs() {
+			seat := Seat{
+				SeatID:     row.RowID + spec.Label,
+				RowID:      row.RowID,
+				SeatNumber: i + 1,
+				SeatType:   row.RowType,
+				Status:     SeatAvailable,
+				Price:      price,
+				BasePrice:  price,
+				Label:      spec.Label,
+				Column:     spec.Column,
+				Kind:       spec.Kind,
+			}
+			if spec.PairedWith != "" {
+				seat.PairedWith = row.RowID + spec.PairedWith
+			}
+			seats = append(seats, seat)
+		}
+	}
+
+	return seats
+}
+
+// parseClock parses HH:MM into minutes after midnight
+func parseClock(value string) (int, error) {
+	clock, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("%q must be a HH:MM time", value)
+	}
+	return clock.Hour()*60 + clock.Minute(), nil
+}
+
+// parseWeekday parses a day name such as "saturday"
+func parseWeekday(name string) (time.Weekday, bool) {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(day.String(), strings.TrimSpace(name)) {
+			return day, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+)
+
+// Session represents a server-side login session
+type Session struct {
+	ID        bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
+	SessionID string        `bson:"session_id" json:"-"`                              // Random identifier embedded in the signed token
+	UserID    bson.ObjectID `bson:"user_id" json:"user_id"`                           // Reference to User
+	UserAgent string        `bson:"user_agent" json:"user_agent"`                     // Browser that created the session
+	IP        string        `bson:"ip" json:"ip"`                                     // Client IP at login
+	ExpiresAt time.Time     `bson:"expires_at" json:"expires_at"`                     // Hard expiry of the session
+	RevokedAt *time.Time    `bson:"revoked_at,omitempty" json:"revoked_at,omitempty"` // Set on logout
+	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
+}
+
+// NewSession creates a new Session instance for a user
+func NewSession(sessionID string, userID bson.ObjectID, userAgent, ip string, ttl time.Duration) *Session {
+	now := time.Now()
+	return &Session{
+		SessionID: sessionID,
+		UserID:    userID,
+		UserAgent: userAgent,
+		IP:        ip,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+}
+
+// IsActive checks if the session is neither revoked nor expired
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
@@ -22,6 +22,9 @@ type Showtime struct {
 	Seats      []Seat        `bson:"seats" json:"seats"`             // Seat availability
 	BookedSeats int          `bson:"booked_seats" json:"booked_seats"` // Count of booked seats
 	TotalSeats  int          `bson:"total_seats" json:"total_seats"`   // Total seats available
+	PriceMultiplier float64        `bson:"price_multiplier,omitempty" json:"price_multiplier,omitempty"`   // Current dynamic pricing multiplier
+	PriceChangeID   *bson.ObjectID `bson:"price_change_id,omitempty" json:"price_change_id,omitempty"`     // Latest dynamic price change
+	PricesUpdatedAt *time.Time     `bson:"prices_updated_at,omitempty" json:"prices_updated_at,omitempty"` // When seat prices were last recomputed
 	CreatedAt   time.Time     `bson:"created_at" json:"created_at"`
 	UpdatedAt   time.Time     `bson:"updated_at" json:"updated_at"`
 }
@@ -40,16 +43,32 @@ type PriceRange struct {
 	TotalPrice   float64 `bson:"total_price" json:"total_price"`
 }
 
+// Seat statuses
+const (
+	SeatAvailable   = "available"
+	SeatHeld        = "held"
+	SeatBooked      = "booked"
+	SeatBlocked     = "blocked"
+	SeatMaintenance = "maintenance"
+)
+
 // Seat represents an individual seat in a showtime
 type Seat struct {
 	SeatID     string `bson:"seat_id" json:"seat_id"`         // A1, A2, B1, etc.
 	RowID      string `bson:"row_id" json:"row_id"`           // A, B, C, etc.
 	SeatNumber int    `bson:"seat_number" json:"seat_number"` // 1, 2, 3, etc.
 	SeatType   string `bson:"seat_type" json:"seat_type"`     // premium, regular
-	Status     string `bson:"status" json:"status"`           // available, booked, blocked, maintenance
+	Status     string `bson:"status" json:"status"`           // available, held, booked, blocked, maintenance
 	Price      float64 `bson:"price" json:"price"`            // Final price for this seat
+	BasePrice  float64 `bson:"base_price,omitempty" json:"base_price,omitempty"` // Time band price dynamic pricing adjusts from
+	Label      string `bson:"label,omitempty" json:"label,omitempty"`             // Printed on the seat; the seat ID is the row ID plus the label
+	Column     int    `bson:"column,omitempty" json:"column,omitempty"`           // Grid column from 1
+	Kind       string `bson:"kind,omitempty" json:"kind,omitempty"`               // standard, wheelchair, companion, loveseat
+	PairedWith string `bson:"paired_with,omitempty" json:"paired_with,omitempty"` // Seat ID of the other half of a loveseat
 	BookedBy   string `bson:"booked_by,omitempty" json:"booked_by,omitempty"` // User ID who booked
 	BlockedAt  *time.Time `bson:"blocked_at,omitempty" json:"blocked_at,omitempty"` // When seat was temporarily blocked
+	HeldFor       string     `bson:"held_for,omitempty" json:"-"`                                  // Booking ID holding or owning the seat
+	HoldExpiresAt *time.Time `bson:"hold_expires_at,omitempty" json:"hold_expires_at,omitempty"` // When a hold or block lapses
 }
 
 // NewShowtime creates a new Showtime instance
@@ -96,12 +115,43 @@ func (s *Showtime) GetSeatByID(seatID string) *Seat {
 	return nil
 }
 
+// UniqueSeatIDs removes duplicate and empty seat IDs while keeping their order
+func UniqueSeatIDs(seatIDs []string) []string {
+	seen := make(map[string]bool, len(seatIDs))
+	unique := make([]string, 0, len(seatIDs))
+	for _, seatID := range seatIDs {
+		if seatID == "" || seen[seatID] {
+			continue
+		}
+		seen[seatID] = true
+		unique = append(unique, seatID)
+	}
+	return unique
+}
+
 // BookSeat marks a seat as booked
 func (s *Showtime) BookSeat(seatID, userID string) bool {
 	seat := s.GetSeatByID(seatID)
-	if seat != nil && seat.Status == "available" {
-		seat.Status = "booked"
+	if seat != nil && seat.Status == SeatAvailable {
+		seat.Status = SeatBooked
+		seat.BookedBy = userID
+		s.BookedSeats++
+		s.UpdateTimestamp()
+		return true
+	}
+	return false
+}
+
+// HoldSeat holds a seat for a booking until payment or until the hold expires.
+// Held seats count towards BookedSeats so they are not offered to anyone else.
+func (s *Showtime) HoldSeat(seatID, userID, bookingID string, duration time.Duration) bool {
+	seat := s.GetSeatByID(seatID)
+	if seat != nil && seat.Status == SeatAvailable {
+		expiresAt := time.Now().Add(duration)
+		seat.Status = SeatHeld
 		seat.BookedBy = userID
+		seat.HeldFor = bookingID
+		seat.HoldExpiresAt = &expiresAt
 		s.BookedSeats++
 		s.UpdateTimestamp()
 		return true
@@ -112,26 +162,30 @@ func (s *Showtime) BookSeat(seatID, userID string) bool {
 // BlockSeat temporarily blocks a seat (for payment processing)
 func (s *Showtime) BlockSeat(seatID string, duration time.Duration) bool {
 	seat := s.GetSeatByID(seatID)
-	if seat != nil && seat.Status == "available" {
-		seat.Status = "blocked"
+	if seat != nil && seat.Status == SeatAvailable {
+		seat.Status = SeatBlocked
 		blockTime := time.Now()
+		expiresAt := blockTime.Add(duration)
 		seat.BlockedAt = &blockTime
+		seat.HoldExpiresAt = &expiresAt
 		s.UpdateTimestamp()
 		return true
 	}
 	return false
 }
 
-// ReleaseSeat releases a blocked or booked seat
+// ReleaseSeat releases a held, blocked or booked seat
 func (s *Showtime) ReleaseSeat(seatID string) bool {
 	seat := s.GetSeatByID(seatID)
-	if seat != nil && (seat.Status == "blocked" || seat.Status == "booked") {
-		if seat.Status == "booked" {
+	if seat != nil && (seat.Status == SeatBlocked || seat.Status == SeatBooked || seat.Status == SeatHeld) {
+		if seat.Status == SeatBooked || seat.Status == SeatHeld {
 			s.BookedSeats--
 		}
-		seat.Status = "available"
+		seat.Status = SeatAvailable
 		seat.BookedBy = ""
 		seat.BlockedAt = nil
+		seat.HeldFor = ""
+		seat.HoldExpiresAt = nil
 		s.UpdateTimestamp()
 		return true
 	}
@@ -24,6 +24,11 @@ type Theater struct {
 		Longitude float64 `bson:"longitude" json:"longitude"`
 	} `bson:"coordinates" json:"coordinates"`
 	Screens   []Screen  `bson:"screens" json:"screens"`
+	CancellationPolicy *CancellationPolicy `bson:"cancellation_policy,omitempty" json:"cancellation_policy,omitempty"` // Defaults to DefaultCancellationPolicy
+	Timezone  string    `bson:"timezone,omitempty" json:"timezone,omitempty"` // IANA zone shows are priced in, e.g. America/New_York
+	PricingSchedule *PricingSchedule `bson:"pricing_schedule,omitempty" json:"pricing_schedule,omitempty"` // Defaults to DefaultPricingSchedule
+	DynamicPricing  *DynamicPricingConfig `bson:"dynamic_pricing,omitempty" json:"dynamic_pricing,omitempty"` // Demand-based seat prices; off when unset
+	SeatGapPolicy   *SeatGapPolicy `bson:"seat_gap_policy,omitempty" json:"seat_gap_policy,omitempty"` // Defaults to DefaultSeatGapPolicy
 	CreatedAt time.Time `bson:"created_at" json:"created_at"`
 	UpdatedAt time.Time `bson:"updated_at" json:"updated_at"`
 }
@@ -53,9 +58,15 @@ type SeatRow struct {
 	RowType  string `bson:"row_type" json:"row_type"` // premium, regular
 	SeatCount int   `bson:"seat_count" json:"seat_count"`
 	Price     Price  `bson:"price" json:"price"`
+	WeekendPrice *Price `bson:"weekend_price,omitempty" json:"weekend_price,omitempty"` // Used on weekends and holidays when set
+	Offset     int        `bson:"offset,omitempty" json:"offset,omitempty"`           // Empty grid columns before the first seat
+	AisleAfter []int      `bson:"aisle_after,omitempty" json:"aisle_after,omitempty"` // Seat numbers followed by an aisle
+	Missing    []int      `bson:"missing,omitempty" json:"missing,omitempty"`         // Seat numbers with no seat, e.g. pillars
+	Seats      []SeatSpec `bson:"seats,omitempty" json:"seats,omitempty"`             // Explicit seats; SeatCount, AisleAfter and Missing are ignored when set
 }
 
-// Price represents pricing for different times
+// Price represents pricing for different times.
+// The band boundaries below are the defaults; theaters may move them with a PricingSchedule.
 type Price struct {
 	Morning   float64 `bson:"morning" json:"morning"`     // Before 12 PM
 	Afternoon float64 `bson:"afternoon" json:"afternoon"` // 12 PM - 6 PM
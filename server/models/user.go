@@ -6,15 +6,26 @@ import (
 	"go.mongodb.org/mongo-driver/v2/bson"
 )
 
+// User roles
+const (
+	RoleCustomer      = "customer"
+	RoleTheaterStaff  = "theater_staff"
+	RoleTheaterAdmin  = "theater_admin"
+	RolePlatformAdmin = "platform_admin"
+)
+
 // User represents a user in the cinema_db database
 type User struct {
-	ID        bson.ObjectID `bson:"_id,omitempty" json:"id,omitempty"`
-	GoogleID  string        `bson:"google_id" json:"google_id"`
-	Email     string        `bson:"email" json:"email"`
-	Name      string        `bson:"name" json:"name"`
-	Picture   string        `bson:"picture" json:"picture"`
-	CreatedAt time.Time     `bson:"created_at" json:"created_at"`
-	UpdatedAt time.Time     `bson:"updated_at" json:"updated_at"`
+	ID                  bson.ObjectID   `bson:"_id,omitempty" json:"id,omitempty"`
+	GoogleID            string          `bson:"google_id" json:"google_id"`
+	Email               string          `bson:"email" json:"email"`
+	Name                string          `bson:"name" json:"name"`
+	Picture             string          `bson:"picture" json:"picture"`
+	Role                string          `bson:"role" json:"role"`                                                   // customer, theater_staff, theater_admin, platform_admin
+	ManagedTheaterIDs   []bson.ObjectID `bson:"managed_theater_ids,omitempty" json:"managed_theater_ids,omitempty"` // Theaters a staff member or theater admin works for
+	CalendarFeedVersion int             `bson:"calendar_feed_version,omitempty" json:"-"`                           // Bumped to revoke every calendar feed URL issued before
+	CreatedAt           time.Time       `bson:"created_at" json:"created_at"`
+	UpdatedAt           time.Time       `bson:"updated_at" json:"updated_at"`
 }
 
 // NewUser creates a new User instance with current timestamps
@@ -25,6 +36,7 @@ func NewUser(googleID, email, name, picture string) *User {
 		Email:     email,
 		Name:      name,
 		Picture:   picture,
+		Role:      RoleCustomer,
 		CreatedAt: now,
 		UpdatedAt: now,
 	}
@@ -34,3 +46,47 @@ func NewUser(googleID, email, name, picture string) *User {
 func (u *User) UpdateTimestamp() {
 	u.UpdatedAt = time.Now()
 }
+
+// IsValidRole checks if role is one of the known user roles
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleCustomer, RoleTheaterStaff, RoleTheaterAdmin, RolePlatformAdmin:
+		return true
+	}
+	return false
+}
+
+// EffectiveRole returns the user's role, treating users created before roles existed as customers
+func (u *User) EffectiveRole() string {
+	if u.Role == "" {
+		return RoleCustomer
+	}
+	return u.Role
+}
+
+// HasRole checks if the user has any of the given roles
+func (u *User) HasRole(roles ...string) bool {
+	role := u.EffectiveRole()
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// CanManageTheater checks if the user may act on behalf of a theater.
+// Platform admins manage every theater; theater staff and admins only the ones assigned to them.
+func (u *User) CanManageTheater(theaterID bson.ObjectID) bool {
+	switch u.EffectiveRole() {
+	case RolePlatformAdmin:
+		return true
+	case RoleTheaterStaff, RoleTheaterAdmin:
+		for _, id := range u.ManagedTheaterIDs {
+			if id == theaterID {
+				return true
+			}
+		}
+	}
+	return false
+}